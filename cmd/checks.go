@@ -0,0 +1,133 @@
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/raesene/eathar/pkg/eathar"
+	"github.com/spf13/cobra"
+)
+
+// groupCmds maps each check group to its top level command
+var groupCmds = map[string]*cobra.Command{
+	"pss":       pssCmd,
+	"isolation": isolationCmd,
+	"windows":   windowsCmd,
+	"rbac":      rbacCmd,
+	"info":      infoCmd,
+}
+
+// newCheckCmd creates the sub-command for a single check
+func newCheckCmd(c eathar.Check) *cobra.Command {
+	return &cobra.Command{
+		Use:   c.ID,
+		Short: c.Short,
+		Long:  c.Description,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChecks([]eathar.Check{c}, cmd)
+		},
+	}
+}
+
+// newAllCmd creates the "all" sub-command for a group, which runs every check in that group
+func newAllCmd(group string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "all",
+		Short: "Runs all the " + group + " checks",
+		Long:  `Runs all the checks in the ` + group + ` group.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChecks(eathar.ChecksInGroup(group), cmd)
+		},
+	}
+}
+
+// incompleteError is returned when one or more checks couldn't complete.
+// The remaining checks still run, but eathar exits with exitIncomplete.
+type incompleteError struct {
+	failed int
+}
+
+func (e *incompleteError) Error() string {
+	return fmt.Sprintf("%d check(s) could not complete", e.failed)
+}
+
+// runChecks runs a list of checks against a single snapshot of the cluster and reports each one in turn
+func runChecks(checks []eathar.Check, cmd *cobra.Command) error {
+	// Past this point errors are about the cluster, not how the command was used
+	cmd.SilenceUsage = true
+	options := cmd.Flags()
+	snapshot, err := eathar.LoadSnapshot(options)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, c := range checks {
+		result := c.Run(snapshot)
+		if result.Err != nil {
+			failed++
+		}
+		eathar.Report(c, result, options)
+	}
+	if failed > 0 {
+		return &incompleteError{failed: failed}
+	}
+	return nil
+}
+
+// maxParallelClusters limits how many clusters a multi-cluster scan talks to at once
+const maxParallelClusters = 8
+
+// runMultiCluster runs the checks against each selected kubeconfig context and reports them together
+func runMultiCluster(checks []eathar.Check, all bool, patterns []string, cmd *cobra.Command) error {
+	options := cmd.Flags()
+	for _, flag := range []string{"context", "cluster", "user", "from-manifests", "from-snapshot"} {
+		if options.Changed(flag) {
+			return fmt.Errorf("--%s can't be used with --all-contexts or --contexts", flag)
+		}
+	}
+	contexts, err := eathar.SelectContexts(options, all, patterns)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	scans := make([]eathar.ClusterScan, len(contexts))
+	limit := make(chan struct{}, maxParallelClusters)
+	var wg sync.WaitGroup
+	for i, kc := range contexts {
+		wg.Add(1)
+		go func(i int, kc eathar.KubeContext) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			fmt.Fprintf(os.Stderr, "Scanning context %s\n", kc.Name)
+			scans[i] = eathar.ScanContext(kc, checks, options)
+		}(i, kc)
+	}
+	wg.Wait()
+	eathar.ReportMultiCluster(eathar.NewMultiClusterReport(checks, scans), options)
+	failed := 0
+	for _, scan := range scans {
+		for _, r := range scan.Results {
+			if r.Err != nil {
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return &incompleteError{failed: failed}
+	}
+	return nil
+}
+
+func init() {
+	for _, c := range eathar.Checks() {
+		groupCmds[c.Group].AddCommand(newCheckCmd(c))
+	}
+	for group, parent := range groupCmds {
+		parent.AddCommand(newAllCmd(group))
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/raesene/eathar/pkg/eathar"
+	"github.com/spf13/cobra"
+)
+
+// collectCmd represents the collect command
+var collectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Saves the cluster objects eathar uses to a snapshot archive",
+	Long: `This command pulls every object eathar's checks use from the cluster
+	and saves them to a single archive, so the checks can be run later without
+	access to the cluster using --from-snapshot e.g.
+	eathar collect -o cluster.tar.gz
+	eathar scan --from-snapshot cluster.tar.gz`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		// collect always reads from a live cluster, so an offline source would be ignored
+		for _, flag := range []string{"from-manifests", "from-snapshot"} {
+			if cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s can't be used with collect", flag)
+			}
+		}
+		// Past this point errors are about the cluster, not how the command was used
+		cmd.SilenceUsage = true
+		// Write to a temporary file next to the output and only rename it once the whole
+		// snapshot is written, so a failed collect doesn't leave a truncated archive behind
+		f, err := os.CreateTemp(filepath.Dir(output), filepath.Base(output)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		err = eathar.NewSnapshot(cmd.Flags()).WriteArchive(f, rootCmd.Version)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("writing snapshot %s: %w", output, err)
+		}
+		if err := os.Rename(f.Name(), output); err != nil {
+			return fmt.Errorf("writing snapshot %s: %w", output, err)
+		}
+		fmt.Fprintf(os.Stderr, "Snapshot written to %s\n", output)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(collectCmd)
+	collectCmd.Flags().StringP("output", "o", "eathar-snapshot.tar.gz", "Snapshot archive to write")
+}
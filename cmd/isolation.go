@@ -0,0 +1,26 @@
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// isolationCmd represents the isolation command
+var isolationCmd = &cobra.Command{
+	Use:   "isolation",
+	Short: "Checks relating to pod isolation settings outside the Pod Security Standards",
+	Long: `These commands check pod isolation settings that aren't covered by the Pod Security Standards,
+	like shared process namespaces, user namespaces and DNS settings.
+	you can use the all command to run all the checks, or run each check individually`,
+	Run: func(cmd *cobra.Command, args []string) {
+		//return the help for the isolation command
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(isolationCmd)
+
+}
@@ -4,8 +4,10 @@ Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
 package cmd
 
 import (
+	"errors"
 	"os"
 
+	"github.com/raesene/eathar/pkg/eathar"
 	"github.com/spf13/cobra"
 )
 
@@ -16,12 +18,24 @@ var rootCmd = &cobra.Command{
 	Long: `Eathar is a program designed to pull information that might be
 	of interest back from Kubernetes clusters.`,
 	Version: "0.2.10",
+	// Flags that are otherwise only parsed once a check needs them are checked up front, so mistakes
+	// in them are usage errors rather than failures in every check
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return eathar.ValidateOptions(cmd.Flags())
+	},
 }
 
+// exitIncomplete is the exit code used when the checks ran but one or more of them couldn't complete
+const exitIncomplete = 3
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
+	var incomplete *incompleteError
+	if errors.As(err, &incomplete) {
+		os.Exit(exitIncomplete)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -33,5 +47,35 @@ func init() {
 	rootCmd.PersistentFlags().BoolP("htmlrep", "", false, "HTML reporting")
 	rootCmd.PersistentFlags().StringP("file", "f", "", "Report file")
 	// Optiont to exclude the kube-system or other namespaces
-	rootCmd.PersistentFlags().StringP("exclude", "e", "", "Comma separated list of namespaces to exclude")
+	rootCmd.PersistentFlags().StringP("exclude", "e", "", "Comma separated list of namespaces to exclude, the same as --exclude-namespaces")
+	// Options to pick which namespaces and pods are checked. Namespace patterns can be exact names, globs or /regexes/
+	rootCmd.PersistentFlags().StringSlice("include-namespaces", nil, "Only check namespaces matching these names, globs or /regexes/")
+	rootCmd.PersistentFlags().StringSlice("exclude-namespaces", nil, "Don't check namespaces matching these names, globs or /regexes/")
+	rootCmd.PersistentFlags().String("namespace-selector", "", "Only check namespaces with labels matching this selector, e.g. env=prod")
+	rootCmd.PersistentFlags().String("pod-selector", "", "Only check pods with labels matching this selector, e.g. app!=debug")
+	rootCmd.PersistentFlags().String("field-selector", "", "Only check pods matching this field selector, e.g. status.phase=Running")
+	// Option to check the pod templates of controllers as well as running pods
+	rootCmd.PersistentFlags().Bool("templates", true, "Also check the pod templates of Deployments, DaemonSets, CronJobs etc, so workloads with no running pods are covered")
+	// Option to report one finding per workload rather than one per pod
+	rootCmd.PersistentFlags().Bool("group-by-workload", false, "Report one finding per workload with a count of its pods, rather than one per pod")
+	// Option to set which host paths are treated as sensitive by the hostpath check
+	rootCmd.PersistentFlags().StringSlice("sensitive-paths", eathar.DefaultSensitivePaths, "Host paths that are high risk to mount, mounts of these paths, paths inside them or paths containing them are flagged")
+	// Option to set which CSI drivers can provide inline volumes without being flagged by the riskyvolumes check
+	rootCmd.PersistentFlags().StringSlice("allowed-csi-drivers", eathar.DefaultCSIDrivers, "CSI drivers trusted to provide inline volumes, inline volumes from other drivers are flagged")
+	// Option to pick the Pod Security Standards version the PSS checks follow
+	rootCmd.PersistentFlags().String("pss-version", "", "Pod Security Standards version to check against, e.g. v1.29 or latest. Defaults to the namespace's enforce-version label, then the cluster's version")
+	// Option to scan manifests instead of a live cluster
+	rootCmd.PersistentFlags().StringSlice("from-manifests", nil, "Scan YAML/JSON manifests instead of a cluster. Takes files, directories or - for stdin")
+	// Option to scan a snapshot archive written by the collect command instead of a live cluster
+	rootCmd.PersistentFlags().String("from-snapshot", "", "Scan a snapshot archive written by eathar collect instead of a cluster")
+	// Options for picking the cluster and identity to connect with, these work the same way as kubectl's
+	rootCmd.PersistentFlags().String("kubeconfig", "", "Path to the kubeconfig file to use")
+	rootCmd.PersistentFlags().String("context", "", "The name of the kubeconfig context to use")
+	rootCmd.PersistentFlags().String("cluster", "", "The name of the kubeconfig cluster to use")
+	rootCmd.PersistentFlags().String("user", "", "The name of the kubeconfig user to use")
+	rootCmd.PersistentFlags().StringP("namespace", "n", "", "Only check workloads and roles in this namespace")
+	rootCmd.PersistentFlags().String("as", "", "Username to impersonate for the checks")
+	rootCmd.PersistentFlags().StringArray("as-group", nil, "Group to impersonate for the checks, can be repeated")
+	rootCmd.PersistentFlags().String("request-timeout", "", "How long to wait for each API request, e.g. 30s. A bare number is seconds")
+	rootCmd.PersistentFlags().Bool("insecure-skip-tls-verify", false, "Don't verify the API server's certificate. This is insecure")
 }
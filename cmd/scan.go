@@ -0,0 +1,44 @@
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/raesene/eathar/pkg/eathar"
+	"github.com/spf13/cobra"
+)
+
+// scanCmd represents the scan command
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Runs the checks from one or more groups",
+	Long: `This command runs every check in the selected groups.
+	By default all groups are run, use --groups to pick a subset
+	e.g. eathar scan --groups pss,rbac`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		groups, _ := cmd.Flags().GetStringSlice("groups")
+		var checks []eathar.Check
+		for _, group := range groups {
+			if _, ok := groupCmds[group]; !ok {
+				return fmt.Errorf("unknown check group %q, valid groups are %v", group, eathar.Groups)
+			}
+			checks = append(checks, eathar.ChecksInGroup(group)...)
+		}
+		allContexts, _ := cmd.Flags().GetBool("all-contexts")
+		contexts, _ := cmd.Flags().GetStringSlice("contexts")
+		if allContexts || len(contexts) > 0 {
+			return runMultiCluster(checks, allContexts, contexts, cmd)
+		}
+		return runChecks(checks, cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+	scanCmd.Flags().StringSliceP("groups", "g", eathar.Groups, "Comma separated list of check groups to run")
+	// Options to scan several clusters in one run
+	scanCmd.Flags().Bool("all-contexts", false, "Scan every context in the kubeconfig and produce a combined report")
+	scanCmd.Flags().StringSlice("contexts", nil, "Comma separated list of kubeconfig contexts to scan, globs like prod-* are allowed")
+}
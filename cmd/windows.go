@@ -0,0 +1,26 @@
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// windowsCmd represents the windows command
+var windowsCmd = &cobra.Command{
+	Use:   "windows",
+	Short: "Checks relating to Windows pods",
+	Long: `These commands check settings that only apply to Windows pods, like the user
+	containers run as, gMSA credential specs and whether Windows pods select Windows nodes.
+	you can use the all command to run all the checks, or run each check individually`,
+	Run: func(cmd *cobra.Command, args []string) {
+		//return the help for the windows command
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(windowsCmd)
+
+}
@@ -0,0 +1,182 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// ArchiveMetadata is stored in metadata.json in a snapshot archive
+type ArchiveMetadata struct {
+	EatharVersion string
+	ServerVersion *version.Info `json:",omitempty"`
+	Context       string        `json:",omitempty"`
+	CollectedAt   time.Time
+	Resources     []string
+}
+
+// archiveFile maps a file in a snapshot archive to the snapshot field it's loaded into.
+// list is a pointer to the field, so it can be filled in by json.Unmarshal
+type archiveFile struct {
+	name string
+	list interface{}
+}
+
+func (s *Snapshot) archiveFiles() []archiveFile {
+	return []archiveFile{
+		{"pods.json", &s.pods},
+		{"namespaces.json", &s.namespaces},
+		{"nodes.json", &s.nodes},
+		{"deployments.json", &s.deployments},
+		{"replicasets.json", &s.replicaSets},
+		{"statefulsets.json", &s.statefulSets},
+		{"daemonsets.json", &s.daemonSets},
+		{"jobs.json", &s.jobs},
+		{"cronjobs.json", &s.cronJobs},
+		{"clusterroles.json", &s.clusterRoles},
+		{"clusterrolebindings.json", &s.clusterRoleBindings},
+		{"roles.json", &s.roles},
+		{"rolebindings.json", &s.roleBindings},
+	}
+}
+
+// loadAll makes sure every resource type has been fetched. An archive with a
+// resource type missing would give misleading results, so any failure is an error.
+func (s *Snapshot) loadAll() error {
+	loaders := []func() error{
+		func() error { _, err := s.Pods(); return err },
+		func() error { _, err := s.Namespaces(); return err },
+		func() error { _, err := s.Nodes(); return err },
+		func() error { _, err := s.Deployments(); return err },
+		func() error { _, err := s.ReplicaSets(); return err },
+		func() error { _, err := s.StatefulSets(); return err },
+		func() error { _, err := s.DaemonSets(); return err },
+		func() error { _, err := s.Jobs(); return err },
+		func() error { _, err := s.CronJobs(); return err },
+		func() error { _, err := s.ClusterRoles(); return err },
+		func() error { _, err := s.ClusterRoleBindings(); return err },
+		func() error { _, err := s.Roles(); return err },
+		func() error { _, err := s.RoleBindings(); return err },
+		func() error { _, err := s.ServerVersion(); return err },
+	}
+	var errs []error
+	for _, load := range loaders {
+		if err := load(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WriteArchive fetches every resource type eathar uses and writes them to a gzipped tar archive,
+// one JSON file per resource type plus metadata.json, so checks can be run against it later
+// with no access to the cluster.
+func (s *Snapshot) WriteArchive(w io.Writer, eatharVersion string) error {
+	if err := s.loadAll(); err != nil {
+		return err
+	}
+	metadata := ArchiveMetadata{
+		EatharVersion: eatharVersion,
+		ServerVersion: s.serverVersion,
+		Context:       currentContext(s.options, s.kubeContext),
+		CollectedAt:   time.Now().UTC(),
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, f := range s.archiveFiles() {
+		if err := writeArchiveFile(tw, f.name, f.list, metadata.CollectedAt); err != nil {
+			return err
+		}
+		metadata.Resources = append(metadata.Resources, f.name)
+	}
+	if err := writeArchiveFile(tw, "metadata.json", metadata, metadata.CollectedAt); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, v interface{}, modTime time.Time) error {
+	js, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", name, err)
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(js)), ModTime: modTime}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = tw.Write(js)
+	return err
+}
+
+// LoadArchive creates a snapshot from an archive written by WriteArchive (the collect command)
+func LoadArchive(path string, options *pflag.FlagSet) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %w", path, err)
+	}
+	s := newOfflineSnapshot(options)
+	files := make(map[string]interface{})
+	for _, af := range s.archiveFiles() {
+		files[af.name] = af.list
+	}
+	s.metadata = &ArchiveMetadata{}
+	files["metadata.json"] = s.metadata
+
+	seen := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot %s: %w", path, err)
+		}
+		list, ok := files[hdr.Name]
+		if !ok {
+			continue
+		}
+		if err := json.NewDecoder(tr).Decode(list); err != nil {
+			return nil, fmt.Errorf("reading %s from snapshot %s: %w", hdr.Name, path, err)
+		}
+		seen[hdr.Name] = true
+	}
+	// A resource file that's missing would otherwise load as an empty list and give a clean report,
+	// so the archive has to hold everything its metadata says was collected
+	if !seen["metadata.json"] {
+		return nil, fmt.Errorf("reading snapshot %s: metadata.json is missing", path)
+	}
+	for _, name := range s.metadata.Resources {
+		if _, known := files[name]; known && !seen[name] {
+			return nil, fmt.Errorf("reading snapshot %s: %s is missing", path, name)
+		}
+	}
+	s.serverVersion = s.metadata.ServerVersion
+	return s, nil
+}
+
+// Metadata returns the metadata of a snapshot loaded from an archive, or nil for any other snapshot
+func (s *Snapshot) Metadata() *ArchiveMetadata {
+	return s.metadata
+}
@@ -1,26 +1,14 @@
 package eathar
 
-import (
-	"context"
-
-	"github.com/rs/zerolog/log"
-	"github.com/spf13/pflag"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-)
-
 // Creates a list of users defined in cluster role binding RBAC rules for the cluster
-func PrincipalList(options *pflag.FlagSet, principal string) []string {
-	principalList := make(map[string]bool)
-	clientset, err := initKubeClient()
+func PrincipalList(s *Snapshot, principal string) ([]string, error) {
+	clusterRoleBindings, err := s.ClusterRoleBindings()
 	if err != nil {
-		log.Print(err)
-	}
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
+		return nil, err
 	}
+	principalList := make(map[string]bool)
 
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
+	for _, clusterRoleBinding := range clusterRoleBindings {
 		for _, subject := range clusterRoleBinding.Subjects {
 			if subject.Kind == principal {
 				if principal == "ServiceAccount" {
@@ -35,5 +23,5 @@ func PrincipalList(options *pflag.FlagSet, principal string) []string {
 	for key := range principalList {
 		principalListSlice = append(principalListSlice, key)
 	}
-	return principalListSlice
+	return principalListSlice, nil
 }
@@ -0,0 +1,92 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// defaultCapabilities are the capabilities containerd, CRI-O and Docker give a container when it doesn't add or drop any
+var defaultCapabilities = []string{"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD", "NET_BIND_SERVICE", "NET_RAW", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT"}
+
+// allCapabilities are every Linux capability, which is what adding ALL or running privileged gives a container
+var allCapabilities = []string{
+	"AUDIT_CONTROL", "AUDIT_READ", "AUDIT_WRITE", "BLOCK_SUSPEND", "BPF", "CHECKPOINT_RESTORE", "CHOWN",
+	"DAC_OVERRIDE", "DAC_READ_SEARCH", "FOWNER", "FSETID", "IPC_LOCK", "IPC_OWNER", "KILL", "LEASE",
+	"LINUX_IMMUTABLE", "MAC_ADMIN", "MAC_OVERRIDE", "MKNOD", "NET_ADMIN", "NET_BIND_SERVICE", "NET_BROADCAST",
+	"NET_RAW", "PERFMON", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYSLOG", "SYS_ADMIN", "SYS_BOOT",
+	"SYS_CHROOT", "SYS_MODULE", "SYS_NICE", "SYS_PACCT", "SYS_PTRACE", "SYS_RAWIO", "SYS_RESOURCE",
+	"SYS_TIME", "SYS_TTY_CONFIG", "WAKE_ALARM",
+}
+
+// dangerousCapabilities are the capabilities that make it easy to break out of a container or attack the node
+var dangerousCapabilities = []string{"BPF", "DAC_READ_SEARCH", "MAC_ADMIN", "MAC_OVERRIDE", "NET_ADMIN", "PERFMON", "SYS_ADMIN", "SYS_BOOT", "SYS_MODULE", "SYS_PTRACE", "SYS_RAWIO", "SYS_TIME"}
+
+// normalizeCapability puts a capability in the form the runtimes use, so cap_sys_admin and SYS_ADMIN are the same
+func normalizeCapability(capability corev1.Capability) string {
+	return strings.TrimPrefix(strings.ToUpper(string(capability)), "CAP_")
+}
+
+// effectiveCapabilities works out the capabilities a container ends up with, the same way containerd does.
+// Adding ALL starts from every capability and dropping ALL starts from none, then the individual adds and
+// drops are applied, so adding ALL and dropping CHOWN gives every capability but CHOWN.
+// Privileged containers get every capability whatever they add or drop.
+func effectiveCapabilities(caps *corev1.Capabilities, privileged bool) []string {
+	if privileged {
+		return allCapabilities
+	}
+	set := make(map[string]bool)
+	for _, c := range defaultCapabilities {
+		set[c] = true
+	}
+	if caps != nil {
+		var add, drop []string
+		for _, c := range caps.Add {
+			add = append(add, normalizeCapability(c))
+		}
+		for _, c := range caps.Drop {
+			drop = append(drop, normalizeCapability(c))
+		}
+		if contains(add, "ALL") {
+			for _, c := range allCapabilities {
+				set[c] = true
+			}
+		}
+		if contains(drop, "ALL") {
+			set = make(map[string]bool)
+		}
+		for _, c := range add {
+			if c != "ALL" {
+				set[c] = true
+			}
+		}
+		for _, c := range drop {
+			delete(set, c)
+		}
+	}
+	var effective []string
+	for c := range set {
+		effective = append(effective, c)
+	}
+	sort.Strings(effective)
+	return effective
+}
+
+// dropsAll reports whether a container drops ALL capabilities, as the Restricted level requires
+func dropsAll(caps *corev1.Capabilities) bool {
+	if caps == nil {
+		return false
+	}
+	for _, c := range caps.Drop {
+		if normalizeCapability(c) == "ALL" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package eathar
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// without returns a copy of caps without the ones listed
+func without(caps []string, remove ...string) []string {
+	var out []string
+	for _, c := range caps {
+		if !contains(remove, c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func TestEffectiveCapabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		caps       *corev1.Capabilities
+		privileged bool
+		want       []string
+	}{
+		{"nil gives defaults", nil, false, defaultCapabilities},
+		{"empty gives defaults", &corev1.Capabilities{}, false, defaultCapabilities},
+		{"add ALL", &corev1.Capabilities{Add: []corev1.Capability{"ALL"}}, false, allCapabilities},
+		{"add ALL drop CHOWN", &corev1.Capabilities{Add: []corev1.Capability{"ALL"}, Drop: []corev1.Capability{"CHOWN"}}, false, without(allCapabilities, "CHOWN")},
+		{"drop ALL", &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}, false, nil},
+		{"drop ALL add NET_BIND_SERVICE", &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}, Add: []corev1.Capability{"NET_BIND_SERVICE"}}, false, []string{"NET_BIND_SERVICE"}},
+		{"drop lower case all", &corev1.Capabilities{Drop: []corev1.Capability{"all"}}, false, nil},
+		{"cap_ prefix is normalized", &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}, Add: []corev1.Capability{"cap_sys_admin"}}, false, []string{"SYS_ADMIN"}},
+		{"drop one default", &corev1.Capabilities{Drop: []corev1.Capability{"CAP_NET_RAW"}}, false, without(defaultCapabilities, "NET_RAW")},
+		{"privileged gives all", nil, true, allCapabilities},
+		{"privileged ignores drop ALL", &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}, true, allCapabilities},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveCapabilities(tt.caps, tt.privileged); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("effectiveCapabilities() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropsAll(t *testing.T) {
+	tests := []struct {
+		name string
+		caps *corev1.Capabilities
+		want bool
+	}{
+		{"nil", nil, false},
+		{"drop ALL", &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}, true},
+		{"drop lower case all", &corev1.Capabilities{Drop: []corev1.Capability{"all"}}, true},
+		{"drop some", &corev1.Capabilities{Drop: []corev1.Capability{"NET_RAW", "CHOWN"}}, false},
+		{"add ALL", &corev1.Capabilities{Add: []corev1.Capability{"ALL"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dropsAll(tt.caps); got != tt.want {
+				t.Errorf("dropsAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
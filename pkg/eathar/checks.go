@@ -0,0 +1,633 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// The kinds of output a check can produce, this decides which report function is used
+const (
+	FindingResult   = "findings"
+	BindingResult   = "bindings"
+	ImageResult     = "images"
+	PrincipalResult = "principals"
+	LevelResult     = "levels"
+	PSAResult       = "psa"
+	CoverageResult  = "coverage"
+)
+
+// Result holds the output of a single check run. Only the field matching Kind is populated.
+// Err is set if the check couldn't complete, in which case there are no results at all
+// rather than a partial set.
+type Result struct {
+	Kind     string
+	Findings []Finding
+	Bindings []Grant
+	Items    []string
+	Levels   *LevelReport
+	PSA      []NamespacePSA
+	Coverage []SeccompCoverage
+	Err      error
+}
+
+// Check describes a single check. The cobra sub-commands, the "all" commands
+// and the scan command are all generated from the list of checks below,
+// so adding an entry here is all that's needed to wire up a new check.
+type Check struct {
+	ID          string
+	Group       string
+	Title       string
+	Short       string
+	Description string
+	Run         func(s *Snapshot) Result
+}
+
+// The check groups, these map to the top level commands
+var Groups = []string{"pss", "isolation", "windows", "rbac", "info"}
+
+var checks = []Check{
+	{
+		ID:    "allowprivesc",
+		Group: "pss",
+		Title: "Allow Privilege Escalation",
+		Short: "List Pods that allow privilege escalation",
+		Description: `This command lists posts that allow privilege escalation.
+	This is a default in general for linux container runtimes and allows
+	for things like sudo to be used in a container to escalate privileges`,
+		Run: findings(AllowPrivEsc),
+	},
+	{
+		ID:    "volumetypes",
+		Group: "pss",
+		Title: "Restricted Volume Types",
+		Short: "List volumes of types the Restricted profile doesn't allow",
+		Description: `This command lists volumes whose type isn't one of those the
+	Restricted profile allows: configMap, csi, downwardAPI, emptyDir, ephemeral,
+	persistentVolumeClaim, projected and secret`,
+		Run: findings(VolumeTypes),
+	},
+	{
+		ID:    "riskyvolumes",
+		Group: "pss",
+		Title: "Risky Volumes",
+		Short: "List volumes of risky types and the reason each is risky",
+		Description: `This command lists gitRepo, nfs, iscsi, rbd, cephfs and flexVolume
+	volumes, along with inline CSI volumes from drivers that aren't on the allowed
+	list (--allowed-csi-drivers), giving the reason each type is risky`,
+		Run: findings(RiskyVolumes),
+	},
+	{
+		ID:    "apparmor",
+		Group: "pss",
+		Title: "Apparmor Disabled",
+		Short: "List containers with apparmor disabled",
+		Description: `This command will list containers whose apparmor profile is set
+	to unconfined, by the appArmorProfile field at the pod or container level or by
+	the older annotation. Apparmor is part of the layers of isolation which should be
+	applied to all containers`,
+		Run: findings(Apparmor),
+	},
+	{
+		ID:    "apparmorlocalhost",
+		Group: "pss",
+		Title: "Apparmor Localhost Profiles",
+		Short: "List containers using localhost apparmor profiles",
+		Description: `This command will list containers that use a Localhost apparmor
+	profile and the name of the profile. Localhost profiles have to be loaded on
+	every node the pod can be scheduled to, so this list can be used to confirm they exist`,
+		Run: findings(ApparmorLocalhost),
+	},
+	{
+		ID:    "capadded",
+		Group: "pss",
+		Title: "Added Capabilities",
+		Short: "Check for containers with added capabilities",
+		Description: `Adding Capabilities to containers over the base set provided
+	by the CRI can risk container breakout. This command lists all the
+	containers with added capabilities`,
+		Run: findings(AddedCapabilities),
+	},
+	{
+		ID:    "capdropped",
+		Group: "pss",
+		Title: "Dropped Capabilities",
+		Short: "List pods and containers that drop capabilities",
+		Description: `This will list containers and pods which drop capabilities.
+	this is a good hardening measure to ensure that containers run with
+	least privilege`,
+		Run: findings(DroppedCapabilities),
+	},
+	{
+		ID:    "caprisk",
+		Group: "pss",
+		Title: "Capability Risk",
+		Short: "List containers with dangerous effective capabilities or that don't drop ALL",
+		Description: `This command works out each container's effective capabilities,
+	starting from the container runtime's default set and applying its adds and drops
+	(including ALL). It lists containers that end up with dangerous capabilities like
+	SYS_ADMIN, SYS_PTRACE, SYS_MODULE, NET_ADMIN, DAC_READ_SEARCH or BPF, and containers
+	that don't drop ALL capabilities as the Restricted profile requires`,
+		Run: findings(CapabilityRisk),
+	},
+	{
+		ID:    "hostipc",
+		Group: "pss",
+		Title: "Host IPC",
+		Short: "Show containers with hostIPC",
+		Description: `Shows containers set to use the host's
+	IPC namespace`,
+		Run: findings(Hostipc),
+	},
+	{
+		ID:    "hostnet",
+		Group: "pss",
+		Title: "Host Network",
+		Short: "List pods with host networking",
+		Description: `This command returns a list of all the pods in the cluster
+	which have host networking enabled.`,
+		Run: findings(Hostnet),
+	},
+	{
+		ID:    "hostpath",
+		Group: "pss",
+		Title: "Host Path",
+		Short: "List pods with hostPath volumes",
+		Description: `This will list any pods with hostPath volumes. This is a security
+	risk as it allows the container to access the host filesystem`,
+		Run: findings(HostPath),
+	},
+	{
+		ID:    "hostpid",
+		Group: "pss",
+		Title: "Host PID",
+		Short: "List pods with host PID access",
+		Description: `This command lists pods which have host PID access
+	This access could be misused by an attacker to affect processes
+	in other containers on running on the host.`,
+		Run: findings(Hostpid),
+	},
+	{
+		ID:    "hostports",
+		Group: "pss",
+		Title: "Host Ports",
+		Short: "List pods with hostPorts",
+		Description: `This will list any pods with hostPorts. This is a security
+	risk as hostPorts cannot be controlled by the network policy engine. Pods
+	using the host's network are listed with every containerPort, as those are
+	opened on the node too. Well known node ports like the kubelet's are marked`,
+		Run: findings(HostPorts),
+	},
+	{
+		ID:    "hostprocess",
+		Group: "pss",
+		Title: "Host Process",
+		Short: "List hostProcess Windows pods",
+		Description: `Lists hostProcess Windows pods. This is a security risk as it allows
+	full access to the underlying node. This is effectively the Windows equivalent
+	of privileged containers`,
+		Run: findings(HostProcess),
+	},
+	{
+		ID:    "privileged",
+		Group: "pss",
+		Title: "Privileged Container",
+		Short: "List Privileged containers",
+		Description: `Lists privileged containers. Containers which run
+	as privileged can easily break out to the underlying host
+	so should be used only where expicitly required.`,
+		Run: findings(Privileged),
+	},
+	{
+		ID:    "seccomp",
+		Group: "pss",
+		Title: "Seccomp Disabled",
+		Short: "Check for disabled seccomp",
+		Description: `Checks whether a seccomp profile has been set. By default
+	Kubernete disables CRI seccomp profiles (e.g. Docker). Containers with no
+	profile and containers explicitly set to Unconfined are both listed, along
+	with where the profile was set (pod, container or the deprecated annotation)`,
+		Run: findings(Seccomp),
+	},
+	{
+		ID:    "seccomplocalhost",
+		Group: "pss",
+		Title: "Seccomp Localhost Profiles",
+		Short: "List containers using localhost seccomp profiles",
+		Description: `This command will list containers that use a Localhost seccomp
+	profile and the path of the profile, relative to the kubelet's seccomp directory.
+	The profile has to exist on every node the pod can be scheduled to`,
+		Run: findings(SeccompLocalhost),
+	},
+	{
+		ID:    "seccompcoverage",
+		Group: "pss",
+		Title: "Seccomp Coverage",
+		Short: "Summarise the seccomp profiles used in each namespace",
+		Description: `This command counts the containers in each namespace by their
+	effective seccomp profile (RuntimeDefault, Localhost, Unconfined or not set),
+	which can be used to plan a move to RuntimeDefault`,
+		Run: func(s *Snapshot) Result {
+			coverage, err := SeccompCoverageSummary(s)
+			return Result{Kind: CoverageResult, Coverage: coverage, Err: err}
+		},
+	},
+	{
+		ID:    "selinux",
+		Group: "pss",
+		Title: "SELinux Options",
+		Short: "List containers with custom SELinux options",
+		Description: `This command will list containers whose seLinuxOptions, set at
+	the pod or container level, use a type the Pod Security Standards don't allow
+	(such as spc_t) or set a user or role, as well as pods that set seLinuxChangePolicy.
+	Pod level options are reported against the pod, even if every container
+	overrides them. On nodes where SELinux is the main confinement these can
+	remove that isolation`,
+		Run: findings(SELinux),
+	},
+	{
+		ID:    "procmount",
+		Group: "pss",
+		Title: "Unmasked Procmount",
+		Short: "List containers with unmasked proc mounts",
+		Description: `This command lists containers with unmasked proc mounts. This is a security risk as it allows
+	access to the proc filesystem on the host which can contain sensitive information`,
+		Run: findings(Procmount),
+	},
+	{
+		ID:    "sysctl",
+		Group: "pss",
+		Title: "Unsafe Sysctl",
+		Short: "List dangerous sysctls",
+		Description: `List sysctls set on pods which are not in the
+	'safe' list`,
+		Run: findings(Sysctl),
+	},
+	{
+		ID:    "runasnonroot",
+		Group: "pss",
+		Title: "Run As Non-Root Not Set",
+		Short: "List containers without runAsNonRoot set to true",
+		Description: `This command lists containers (including init and ephemeral
+	containers) where runAsNonRoot is missing or false once the pod and container
+	securityContexts are resolved, and shows which level set the value`,
+		Run: findings(RunAsNonRoot),
+	},
+	{
+		ID:    "runasrootuser",
+		Group: "pss",
+		Title: "Run As Root User",
+		Short: "List containers set to run as UID 0",
+		Description: `This command lists containers (including init and ephemeral
+	containers) with runAsUser explicitly set to 0, and shows whether that was
+	set in the pod or the container securityContext`,
+		Run: findings(RunAsRootUser),
+	},
+	{
+		ID:    "runasrootgroup",
+		Group: "pss",
+		Title: "Run As Root Group",
+		Short: "List containers set to run as GID 0",
+		Description: `This command lists containers (including init and ephemeral
+	containers) with runAsGroup explicitly set to 0, and shows whether that was
+	set in the pod or the container securityContext`,
+		Run: findings(RunAsRootGroup),
+	},
+	{
+		ID:          "rootfsgroup",
+		Group:       "pss",
+		Title:       "Root FSGroup",
+		Short:       "List pods with fsGroup set to 0",
+		Description: `This command lists pods with fsGroup set to 0 (the root group)`,
+		Run:         findings(RootFSGroup),
+	},
+	{
+		ID:          "rootsupplementalgroups",
+		Group:       "pss",
+		Title:       "Root Supplemental Group",
+		Short:       "List pods with 0 in supplementalGroups",
+		Description: `This command lists pods with 0 (the root group) in their supplementalGroups`,
+		Run:         findings(RootSupplementalGroups),
+	},
+	{
+		ID:    "level",
+		Group: "pss",
+		Title: "Pod Security Standards Level",
+		Short: "Show the highest Pod Security Standards level each pod and namespace meets",
+		Description: `This command evaluates every Baseline and Restricted control
+	against each pod and lists the controls it violates. The results are then
+	rolled up per namespace to show the highest level (privileged, baseline or
+	restricted) each namespace would pass if it were enforced today`,
+		Run: func(s *Snapshot) Result {
+			levels, err := PSSLevels(s)
+			return Result{Kind: LevelResult, Levels: levels, Err: err}
+		},
+	},
+	{
+		ID:    "psa",
+		Group: "pss",
+		Title: "Pod Security Admission Labels",
+		Short: "Audit namespace Pod Security Admission labels and what raising them would break",
+		Description: `This command reads the pod-security.kubernetes.io enforce, audit
+	and warn labels (and their -version labels) on every namespace. For each
+	namespace it suggests the highest enforce level the current pods allow and
+	lists the pods that would be rejected if enforcement were raised to baseline
+	or restricted, so it can be used to plan a migration to Pod Security Admission`,
+		Run: func(s *Snapshot) Result {
+			psa, err := PSAAudit(s)
+			return Result{Kind: PSAResult, PSA: psa, Err: err}
+		},
+	},
+	{
+		ID:    "shareprocessnamespace",
+		Group: "isolation",
+		Title: "Shared Process Namespace",
+		Short: "List pods with shareProcessNamespace set",
+		Description: `This command lists pods with shareProcessNamespace: true. Their
+	containers can see and signal each other's processes, and read each other's
+	filesystems and environment variables through /proc`,
+		Run: findings(ShareProcessNamespace),
+	},
+	{
+		ID:    "hostusers",
+		Group: "isolation",
+		Title: "Host User Namespace",
+		Short: "List pods that don't use user namespaces",
+		Description: `This command lists pods that don't set hostUsers: false, so root
+	in their containers is root on the node. It's only run when the cluster is new
+	enough to support user namespaces (1.33 and later) and skips Windows pods and
+	pods using host namespaces, which can't use them`,
+		Run: findings(HostUsers),
+	},
+	{
+		ID:    "hostaliases",
+		Group: "isolation",
+		Title: "Host Aliases",
+		Short: "List hostAliases that override cluster or cloud metadata names",
+		Description: `This command lists hostAliases entries for names such as
+	kubernetes.default, anything ending in .svc, .cluster.local or .internal and
+	the cloud metadata service names. Pointing these at another address lets
+	the pod's traffic for them be intercepted`,
+		Run: findings(HostAliases),
+	},
+	{
+		ID:    "dnspolicy",
+		Group: "isolation",
+		Title: "DNS Policy",
+		Short: "List pods that don't use the cluster's DNS",
+		Description: `This command lists pods with dnsPolicy Default, which uses the
+	node's resolvers, or None, and pods that add their own nameservers. DNS
+	lookups from these pods bypass any controls on the cluster's DNS`,
+		Run: findings(DNSPolicy),
+	},
+	{
+		ID:    "servicelinks",
+		Group: "isolation",
+		Title: "Service Links",
+		Short: "List pods that don't disable service links",
+		Description: `This command lists pods that don't set enableServiceLinks: false,
+	so every container gets environment variables with the address and port of
+	each service in the namespace, which helps anyone in the container find them`,
+		Run: findings(ServiceLinks),
+	},
+	{
+		ID:    "windowsadmin",
+		Group: "windows",
+		Title: "Windows Admin User",
+		Short: "List Windows containers running as an administrator account",
+		Description: `This command lists containers whose runAsUserName is
+	ContainerAdministrator or another administrator account such as
+	NT AUTHORITY\SYSTEM. These have full control of the container and more
+	of the node to attack if they break out`,
+		Run: findings(WindowsAdminUser),
+	},
+	{
+		ID:    "gmsa",
+		Group: "windows",
+		Title: "GMSA Credential Spec",
+		Short: "List Windows containers using gMSA credential specs",
+		Description: `This command lists containers that set gmsaCredentialSpecName or
+	gmsaCredentialSpec. These can authenticate to Active Directory as the group
+	managed service account, so anyone who compromises them gets its access`,
+		Run: findings(GMSA),
+	},
+	{
+		ID:    "windowsnodeselector",
+		Group: "windows",
+		Title: "Windows Node Selector",
+		Short: "List Windows pods that don't select Windows nodes",
+		Description: `This command lists Windows pods that don't have a kubernetes.io/os
+	nodeSelector or required node affinity for windows. Pods are treated as
+	Windows pods if spec.os is windows or they're running on a Windows node.
+	Without the selector they can be scheduled onto Linux nodes and may be
+	missed by policies that go by the label`,
+		Run: findings(WindowsNodeSelector),
+	},
+	{
+		ID:    "windowshostnetwork",
+		Group: "windows",
+		Title: "Windows Host Network",
+		Short: "List Windows pods using the host network",
+		Description: `This command lists Windows pods with hostNetwork: true, which
+	share the network of the Windows node they run on. As well as the pods the
+	windowsnodeselector check treats as Windows pods, this includes pods that
+	only select Windows nodes`,
+		Run: findings(WindowsHostNetwork),
+	},
+	{
+		ID:          "clusteradminusers",
+		Group:       "rbac",
+		Title:       "Cluster Admin Users",
+		Short:       "A list of users/groups/service accounts with cluster-admin role",
+		Description: `This provides a list of users/groups/service accounts with cluster-admin role`,
+		Run:         bindings(GetClusterAdminUsers),
+	},
+	{
+		ID:    "getsecretsusers",
+		Group: "rbac",
+		Title: "Users with access to secrets",
+		Short: "Lists users/groups/service accounts with access to read secrets",
+		Description: `This command lists users/groups/service accounts with access to read secrets
+	either via the get verb or via the list verb (both of which allow you to read the contents of the secret).`,
+		Run: bindings(GetSecretsUsers),
+	},
+	{
+		ID:          "persistentvolumecreationusers",
+		Group:       "rbac",
+		Title:       "Users with access to create persistent volumes",
+		Short:       "Lists users/groups/service accounts with access to create persistent volumes",
+		Description: `This command lists users/groups/service accounts with access to create persistent volumes`,
+		Run:         bindings(CreatePVUsers),
+	},
+	{
+		ID:          "impersonateusers",
+		Group:       "rbac",
+		Title:       "Users with access to impersonate",
+		Short:       "Lists users/groups/service accounts with access to the impersonate verb",
+		Description: `Lists users/groups/service accounts with access to the impersonate verb`,
+		Run:         bindings(ImpersonateUsers),
+	},
+	{
+		ID:          "escalateusers",
+		Group:       "rbac",
+		Title:       "Users with access to escalate",
+		Short:       "Lists users/groups/service accounts with access to the escalate verb",
+		Description: `Lists users/groups/service accounts with access to the escalate verb`,
+		Run:         bindings(EscalateUsers),
+	},
+	{
+		ID:          "bindusers",
+		Group:       "rbac",
+		Title:       "Users with access to bind",
+		Short:       "Lists users/groups/service accounts with access to the bind verb",
+		Description: `Lists users/groups/service accounts with access to the bind verb`,
+		Run:         bindings(BindUsers),
+	},
+	{
+		ID:          "validatingwebhookusers",
+		Group:       "rbac",
+		Title:       "Users with access to create or modify validatingadmissionwebhookconfigurations",
+		Short:       "List the users that have access to modify validating webhooks",
+		Description: `List the users that have access to modify validating webhooks`,
+		Run:         bindings(ValidatingWebhookUsers),
+	},
+	{
+		ID:          "mutatingwebhookusers",
+		Group:       "rbac",
+		Title:       "Users with access to create or modify mutatingadmissionwebhookconfigurations",
+		Short:       "List the users that have access to modify mutating webhooks",
+		Description: `List the users that have access to modify mutating webhooks`,
+		Run:         bindings(MutatingWebhookUsers),
+	},
+	{
+		ID:    "wildcardusers",
+		Group: "rbac",
+		Title: "Users with wildcard access to all resources",
+		Short: "List all users with wildcard permissions to all resources",
+		Description: `This command finds roles and clusterroles that provide access to all
+	resources via wildcard (*), and then lists all users/groups/service accounts
+	associated with them via rolebindings and clusterrolebindings.`,
+		Run: bindings(WildcardAccess),
+	},
+	{
+		ID:          "createserviceaccountokenusers",
+		Group:       "rbac",
+		Title:       "Users with create access to service account tokens",
+		Short:       "Lists users who can create service account tokens",
+		Description: `Lists users who can create service account tokens, across the cluster or in a namespace.`,
+		Run:         bindings(CreateServiceAccountTokens),
+	},
+	{
+		ID:    "approvecsrusers",
+		Group: "rbac",
+		Title: "Users with update rights to CSR approvals",
+		Short: "Lists users who can approve CSRs via update access to the CSR resource",
+		Description: `Lists users/groups/service accounts that can approve CSRs via update access to the CSR resource
+	This is a slight hack as more rights are needed to approve a CSR than just update access to the CSR resource
+	but update is the key permission`,
+		Run: bindings(UpdateCSRApproval),
+	},
+	{
+		ID:          "imageList",
+		Group:       "info",
+		Title:       "Image List",
+		Short:       "List images used in the cluster",
+		Description: `This will provide a list of images used in the cluster`,
+		Run: func(s *Snapshot) Result {
+			images, err := ImageList(s)
+			return Result{Kind: ImageResult, Items: images, Err: err}
+		},
+	},
+	{
+		ID:    "clusterUserList",
+		Group: "info",
+		Title: "Cluster User List",
+		Short: "A list of users defined in cluster role bindings",
+		Description: `this command provides a list of any users defined
+	in cluster role bindings.`,
+		Run: principals("User"),
+	},
+	{
+		ID:    "clusterGroupList",
+		Group: "info",
+		Title: "Cluster Group List",
+		Short: "A list of Groups defined in cluster role bindings",
+		Description: `this command provides a list of any groups defined
+	in cluster role bindings.`,
+		Run: principals("Group"),
+	},
+	{
+		ID:    "clusterSaList",
+		Group: "info",
+		Title: "Cluster Service Account List",
+		Short: "A list of Service Accounts defined in cluster role bindings",
+		Description: `this command provides a list of any service accounts defined
+	in cluster role bindings.`,
+		Run: principals("ServiceAccount"),
+	},
+}
+
+// Checks returns every registered check
+func Checks() []Check {
+	return checks
+}
+
+// ChecksInGroup returns the registered checks belonging to a single group, in registration order
+func ChecksInGroup(group string) []Check {
+	var groupChecks []Check
+	for _, c := range checks {
+		if c.Group == group {
+			groupChecks = append(groupChecks, c)
+		}
+	}
+	return groupChecks
+}
+
+// Report sends the result of a check to the report function matching its kind
+func Report(c Check, r Result, options *pflag.FlagSet) {
+	r = groupFindings(r, options)
+	if r.Err != nil {
+		ReportError(r.Err, options, c.Title)
+		return
+	}
+	switch r.Kind {
+	case FindingResult:
+		ReportPSS(r.Findings, options, c.Title)
+	case BindingResult:
+		ReportRBAC(r.Bindings, options, c.Title)
+	case ImageResult:
+		ReportImage(r.Items, options, c.Title)
+	case PrincipalResult:
+		ReportPrincipal(r.Items, options, c.Title)
+	case LevelResult:
+		ReportLevels(r.Levels, options, c.Title)
+	case PSAResult:
+		ReportPSA(r.PSA, options, c.Title)
+	case CoverageResult:
+		ReportSeccompCoverage(r.Coverage, options, c.Title)
+	}
+}
+
+func findings(run func(s *Snapshot) ([]Finding, error)) func(s *Snapshot) Result {
+	return func(s *Snapshot) Result {
+		f, err := run(s)
+		return Result{Kind: FindingResult, Findings: f, Err: err}
+	}
+}
+
+func bindings(run func(s *Snapshot) ([]Grant, error)) func(s *Snapshot) Result {
+	return func(s *Snapshot) Result {
+		b, err := run(s)
+		return Result{Kind: BindingResult, Bindings: b, Err: err}
+	}
+}
+
+func principals(principal string) func(s *Snapshot) Result {
+	return func(s *Snapshot) Result {
+		p, err := PrincipalList(s, principal)
+		return Result{Kind: PrincipalResult, Items: p, Err: err}
+	}
+}
@@ -6,68 +6,86 @@ Copyright © 2022 Rory McCune <rorym@mccune.org.uk>
 */
 
 import (
-	"context"
-	"strings"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
 
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/pflag"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-func initKubeClient() (*kubernetes.Clientset, error) {
+// clientConfig builds the client config from the kubeconfig flags, which work the same way as kubectl's.
+// kubeContext overrides --context when it's set, this is used when scanning several contexts in one run.
+func clientConfig(options *pflag.FlagSet, kubeContext string) clientcmd.ClientConfig {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
-	config, err := kubeConfig.ClientConfig()
+	loadingRules.ExplicitPath, _ = options.GetString("kubeconfig")
+	overrides := &clientcmd.ConfigOverrides{}
+	overrides.CurrentContext, _ = options.GetString("context")
+	if kubeContext != "" {
+		overrides.CurrentContext = kubeContext
+	}
+	overrides.Context.Cluster, _ = options.GetString("cluster")
+	overrides.Context.AuthInfo, _ = options.GetString("user")
+	overrides.AuthInfo.Impersonate, _ = options.GetString("as")
+	overrides.AuthInfo.ImpersonateGroups, _ = options.GetStringArray("as-group")
+	overrides.ClusterInfo.InsecureSkipTLSVerify, _ = options.GetBool("insecure-skip-tls-verify")
+	overrides.Timeout, _ = options.GetString("request-timeout")
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// initKubeClient connects to the cluster selected by the kubeconfig flags. If there's no
+// kubeconfig to load and eathar is running in a pod, the in-cluster config is used instead.
+func initKubeClient(options *pflag.FlagSet, kubeContext string) (*kubernetes.Clientset, error) {
+	config, err := clientConfig(options, kubeContext).ClientConfig()
 	if err != nil {
-		log.Printf("initKubeClient: failed creating ClientConfig with %v", err)
-		return nil, err
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	// The in-cluster config ignores most overrides, so make sure impersonation and the timeout still apply
+	if as, _ := options.GetString("as"); as != "" {
+		config.Impersonate.UserName = as
+		config.Impersonate.Groups, _ = options.GetStringArray("as-group")
+	}
+	if timeout, _ := options.GetString("request-timeout"); timeout != "" {
+		config.Timeout, err = parseTimeout(timeout)
+		if err != nil {
+			return nil, err
+		}
 	}
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Printf("initKubeClient: failed creating Clientset with %v", err)
-		return nil, err
+		return nil, fmt.Errorf("creating client: %w", err)
 	}
 	return clientset, nil
 }
 
-func connectWithPods(options *pflag.FlagSet) *corev1.PodList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
+// parseTimeout parses --request-timeout like kubectl does, a bare number is taken as seconds
+func parseTimeout(timeout string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(timeout); err == nil {
+		return time.Duration(seconds) * time.Second, nil
 	}
-	pods, err := clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	d, err := time.ParseDuration(timeout)
 	if err != nil {
-		log.Print(err)
+		return 0, fmt.Errorf("invalid --request-timeout %q, must be a number of seconds or a duration like 30s", timeout)
 	}
+	return d, nil
+}
 
-	exclude, err := options.GetString("exclude")
-
-	var excludeList []string
-	if exclude != "" {
-		excludeList = strings.Split(exclude, ",")
+// currentContext returns the name of the kubeconfig context eathar connects with
+func currentContext(options *pflag.FlagSet, kubeContext string) string {
+	if kubeContext != "" {
+		return kubeContext
 	}
-	if err != nil {
-		log.Print(err)
+	if context, _ := options.GetString("context"); context != "" {
+		return context
 	}
-	filteredPods := &corev1.PodList{}
-	for _, pod := range pods.Items {
-		if len(excludeList) > 0 {
-			excluded := false
-			for _, s := range excludeList {
-				if strings.Contains(pod.Namespace, s) {
-					excluded = true
-					break
-				}
-			}
-			if excluded {
-				continue
-			}
+	config, err := clientConfig(options, "").RawConfig()
+	if err != nil || config.CurrentContext == "" {
+		if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+			return "in-cluster"
 		}
-		filteredPods.Items = append(filteredPods.Items, pod)
+		return ""
 	}
-
-	return filteredPods
-}
\ No newline at end of file
+	return config.CurrentContext
+}
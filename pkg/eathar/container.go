@@ -1,12 +1,13 @@
 package eathar
 
-import "github.com/spf13/pflag"
-
 //Creates a list of images in use in the cluster
-func ImageList(options *pflag.FlagSet) []string {
+func ImageList(s *Snapshot) ([]string, error) {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
 	imageList := make(map[string]bool)
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
+	for _, pod := range targets {
 		for _, container := range pod.Spec.Containers {
 			imageList[container.Image] = true
 		}
@@ -18,6 +19,6 @@ func ImageList(options *pflag.FlagSet) []string {
 		imageListSlice = append(imageListSlice, key)
 	}
 
-	return imageListSlice
+	return imageListSlice, nil
 	//reportImage(imageListSlice, options, "Image List")
 }
@@ -0,0 +1,86 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"path"
+	"strings"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The risk classes given to host path mounts, from most to least serious
+const (
+	RiskCritical = "critical"
+	RiskHigh     = "high"
+	RiskMedium   = "medium"
+	RiskLow      = "low"
+)
+
+// DefaultSensitivePaths are the host paths that give a container control of the node, or secrets from it,
+// when they're mounted. --sensitive-paths replaces this list.
+var DefaultSensitivePaths = []string{
+	"/",
+	"/etc",
+	"/proc",
+	"/sys",
+	"/dev",
+	"/boot",
+	"/root",
+	"/home",
+	"/etc/kubernetes",
+	"/var/lib/kubelet",
+	"/var/lib/etcd",
+	"/var/lib/docker",
+	"/var/lib/containerd",
+	"/var/run/docker.sock",
+	"/run/docker.sock",
+	"/var/run/containerd/containerd.sock",
+	"/run/containerd/containerd.sock",
+	"/var/run/crio/crio.sock",
+	"/run/crio/crio.sock",
+}
+
+// sensitivePaths returns the sensitive path list from --sensitive-paths, or the default list if the flag isn't there
+func sensitivePaths(options *pflag.FlagSet) []string {
+	if options != nil {
+		if paths, err := options.GetStringSlice("sensitive-paths"); err == nil {
+			return paths
+		}
+	}
+	return DefaultSensitivePaths
+}
+
+// isSensitivePath reports whether a host path is one of the sensitive paths, is inside one, or contains one
+// (mounting /var exposes /var/lib/kubelet). / only matches itself, otherwise every path would be inside it.
+func isSensitivePath(hostPath string, sensitive []string) bool {
+	hostPath = path.Clean(hostPath)
+	for _, s := range sensitive {
+		s = path.Clean(s)
+		if hostPath == s || hostPath == "/" || (s != "/" && strings.HasPrefix(hostPath, s+"/")) || strings.HasPrefix(s, hostPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// hostPathRisk classifies a host path mount. Sensitive paths are critical if the container can write to them
+// and high if it can only read them, anything else is medium if writable and low if read only.
+// Bidirectional propagation lets the container's mounts reach the host, so it counts as writable, as does
+// a socket since a read only mount doesn't stop the container connecting to it.
+func hostPathRisk(hostPath string, readOnly bool, propagation string, sensitive []string) string {
+	writable := !readOnly || propagation == string(corev1.MountPropagationBidirectional) || strings.HasSuffix(hostPath, ".sock")
+	switch {
+	case isSensitivePath(hostPath, sensitive) && writable:
+		return RiskCritical
+	case isSensitivePath(hostPath, sensitive):
+		return RiskHigh
+	case writable:
+		return RiskMedium
+	}
+	return RiskLow
+}
@@ -0,0 +1,73 @@
+package eathar
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestIsSensitivePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostPath string
+		want     bool
+	}{
+		{"exact", "/etc/kubernetes", true},
+		{"child", "/etc/kubernetes/pki", true},
+		{"parent", "/var", true},
+		{"parent of socket", "/run/containerd", true},
+		{"trailing slash", "/var/lib/kubelet/", true},
+		{"unclean path", "/var/lib/../lib/kubelet", true},
+		{"root", "/", true},
+		{"not inside root", "/data", false},
+		{"sibling", "/var/lib/kubelet-plugins", false},
+		{"unrelated", "/opt/app", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSensitivePath(tt.hostPath, DefaultSensitivePaths); got != tt.want {
+				t.Errorf("isSensitivePath(%q) = %v, want %v", tt.hostPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostPathRisk(t *testing.T) {
+	bidirectional := string(corev1.MountPropagationBidirectional)
+	hostToContainer := string(corev1.MountPropagationHostToContainer)
+	tests := []struct {
+		name        string
+		hostPath    string
+		readOnly    bool
+		propagation string
+		want        string
+	}{
+		{"writable sensitive", "/etc", false, "", RiskCritical},
+		{"read only sensitive", "/etc", true, "", RiskHigh},
+		{"read only sensitive child", "/etc/kubernetes/pki", true, "", RiskHigh},
+		{"read only bidirectional sensitive", "/var/lib/kubelet", true, bidirectional, RiskCritical},
+		{"read only host to container sensitive", "/var/lib/kubelet", true, hostToContainer, RiskHigh},
+		{"read only socket", "/var/run/docker.sock", true, "", RiskCritical},
+		{"writable other", "/data", false, "", RiskMedium},
+		{"read only other", "/data", true, "", RiskLow},
+		{"read only bidirectional other", "/data", true, bidirectional, RiskMedium},
+		{"read only other socket", "/tmp/app.sock", true, "", RiskMedium},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostPathRisk(tt.hostPath, tt.readOnly, tt.propagation, DefaultSensitivePaths); got != tt.want {
+				t.Errorf("hostPathRisk(%q, %v, %q) = %s, want %s", tt.hostPath, tt.readOnly, tt.propagation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSensitivePathCustomList(t *testing.T) {
+	sensitive := []string{"/srv/secrets"}
+	if !isSensitivePath("/srv/secrets/db", sensitive) {
+		t.Error("child of a custom sensitive path isn't sensitive")
+	}
+	if isSensitivePath("/etc", sensitive) {
+		t.Error("default sensitive path matched when the list was replaced")
+	}
+}
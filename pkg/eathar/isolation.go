@@ -0,0 +1,154 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// userNamespacesVersion is the first Kubernetes version with user namespace support turned on by default
+var userNamespacesVersion = version.MajorMinor(1, 33)
+
+// internalHostnames are names that hostAliases shouldn't point somewhere else, as they belong to the cluster
+// or the cloud provider's metadata service. Names ending in internalSuffixes are matched too.
+var internalHostnames = []string{"kubernetes", "kubernetes.default", "metadata", "instance-data", "localhost"}
+
+var internalSuffixes = []string{".svc", ".cluster.local", ".internal"}
+
+// ShareProcessNamespace lists pods whose containers share a process namespace, so each can see and signal
+// the others' processes and read their filesystems through /proc
+func ShareProcessNamespace(s *Snapshot) ([]Finding, error) {
+	var shared []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		if pod.Spec.ShareProcessNamespace != nil && *pod.Spec.ShareProcessNamespace {
+			p := newFinding("Shared Process Namespace", pod, "")
+			shared = append(shared, p)
+		}
+	}
+	return shared, nil
+}
+
+// HostUsers lists pods that don't set hostUsers: false, so root in the container is root on the node.
+// Nothing is listed if the cluster is too old to support user namespaces. Manifests have no server
+// version so they're always checked. Windows pods and pods using host namespaces can't use them and are skipped.
+func HostUsers(s *Snapshot) ([]Finding, error) {
+	info, err := s.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	if info != nil {
+		v, err := version.ParseGeneric(info.GitVersion)
+		if err == nil && v.LessThan(userNamespacesVersion) {
+			return nil, nil
+		}
+	}
+	var hostusers []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		if isWindows(pod.Spec) || pod.Spec.HostNetwork || pod.Spec.HostPID || pod.Spec.HostIPC {
+			continue
+		}
+		if pod.Spec.HostUsers == nil || *pod.Spec.HostUsers {
+			p := newFinding("Host User Namespace", pod, "")
+			hostusers = append(hostusers, p)
+		}
+	}
+	return hostusers, nil
+}
+
+// HostAliases lists hostAliases entries that point cluster or cloud metadata names somewhere else
+func HostAliases(s *Snapshot) ([]Finding, error) {
+	var aliases []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, alias := range pod.Spec.HostAliases {
+			for _, hostname := range alias.Hostnames {
+				if isInternalHostname(hostname) {
+					p := newFinding("Host Aliases", pod, "")
+					p.Option = hostname
+					p.Value = alias.IP
+					aliases = append(aliases, p)
+				}
+			}
+		}
+	}
+	return aliases, nil
+}
+
+func isInternalHostname(hostname string) bool {
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+	if contains(internalHostnames, hostname) {
+		return true
+	}
+	for _, suffix := range internalSuffixes {
+		if strings.HasSuffix(hostname, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// DNSPolicy lists pods that don't use the cluster's DNS, either by using the node's resolvers (Default)
+// or their own (None), and pods that add their own nameservers to the cluster's
+func DNSPolicy(s *Snapshot) ([]Finding, error) {
+	var dns []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		var nameservers []string
+		if pod.Spec.DNSConfig != nil {
+			nameservers = pod.Spec.DNSConfig.Nameservers
+		}
+		policy := pod.Spec.DNSPolicy
+		if policy == corev1.DNSDefault || policy == corev1.DNSNone || nameservers != nil {
+			if policy == "" {
+				policy = corev1.DNSClusterFirst
+			}
+			p := newFinding("DNS Policy", pod, "")
+			p.Option = "dnsPolicy " + string(policy)
+			switch {
+			case nameservers != nil:
+				p.Value = "nameservers " + strings.Join(nameservers, ",")
+			case policy == corev1.DNSDefault:
+				p.Value = "uses the node's resolvers"
+			}
+			dns = append(dns, p)
+		}
+	}
+	return dns, nil
+}
+
+// ServiceLinks lists pods that don't set enableServiceLinks: false, so every container gets environment
+// variables with the address of each service in the namespace
+func ServiceLinks(s *Snapshot) ([]Finding, error) {
+	var links []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		if pod.Spec.EnableServiceLinks == nil || *pod.Spec.EnableServiceLinks {
+			p := newFinding("Service Links", pod, "")
+			links = append(links, p)
+		}
+	}
+	return links, nil
+}
@@ -0,0 +1,171 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// RefAnnotation is added to every object loaded from a manifest. It records the file
+// and the index of the document within that file that the object came from.
+const RefAnnotation = "eathar.io/manifest-ref"
+
+// LoadManifests creates a snapshot from YAML or JSON manifests rather than a live cluster.
+// Each path can be a file, a directory (which is walked for .yaml, .yml and .json files) or - for stdin.
+func LoadManifests(paths []string, options *pflag.FlagSet) (*Snapshot, error) {
+	s := newOfflineSnapshot(options)
+	for _, path := range paths {
+		if path == "-" {
+			if err := s.loadManifestStream(os.Stdin, "stdin"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			// Only filter on extension when walking a directory, files named explicitly are always read
+			if file != path {
+				switch strings.ToLower(filepath.Ext(file)) {
+				case ".yaml", ".yml", ".json":
+				default:
+					return nil
+				}
+			}
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return s.loadManifestStream(f, file)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("loading manifests from %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// loadManifestStream splits a stream into YAML documents and adds each one to the snapshot
+func (s *Snapshot) loadManifestStream(r io.Reader, name string) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	for index := 0; ; index++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := s.addManifest(doc, fmt.Sprintf("%s#%d", name, index)); err != nil {
+			return err
+		}
+	}
+}
+
+// addManifest decodes a single document and stores it against the matching resource type.
+// Documents for kinds that no check looks at are skipped. A document that can't be decoded is an
+// error, as skipping it would leave whatever it holds out of the results without any sign of it.
+func (s *Snapshot) addManifest(doc []byte, ref string) error {
+	if len(bytes.TrimSpace(doc)) == 0 {
+		return nil
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(doc, nil, nil)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) || runtime.IsMissingKind(err) {
+			return nil
+		}
+		return fmt.Errorf("decoding %s: %w", ref, err)
+	}
+	switch o := obj.(type) {
+	case *corev1.List:
+		for i, item := range o.Items {
+			if err := s.addManifest(item.Raw, fmt.Sprintf("%s/%d", ref, i)); err != nil {
+				return err
+			}
+		}
+	case *corev1.Pod:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.pods.Items = append(s.pods.Items, *o)
+	case *corev1.Namespace:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		s.namespaces.Items = append(s.namespaces.Items, *o)
+	case *appsv1.Deployment:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.deployments.Items = append(s.deployments.Items, *o)
+	case *appsv1.ReplicaSet:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.replicaSets.Items = append(s.replicaSets.Items, *o)
+	case *appsv1.StatefulSet:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.statefulSets.Items = append(s.statefulSets.Items, *o)
+	case *appsv1.DaemonSet:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.daemonSets.Items = append(s.daemonSets.Items, *o)
+	case *batchv1.Job:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.jobs.Items = append(s.jobs.Items, *o)
+	case *batchv1.CronJob:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.cronJobs.Items = append(s.cronJobs.Items, *o)
+	case *rbacv1.ClusterRole:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		s.clusterRoles.Items = append(s.clusterRoles.Items, *o)
+	case *rbacv1.ClusterRoleBinding:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		s.clusterRoleBindings.Items = append(s.clusterRoleBindings.Items, *o)
+	case *rbacv1.Role:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.roles.Items = append(s.roles.Items, *o)
+	case *rbacv1.RoleBinding:
+		setManifestRef(&o.ObjectMeta.Annotations, ref)
+		defaultNamespace(&o.Namespace)
+		s.roleBindings.Items = append(s.roleBindings.Items, *o)
+	}
+	return nil
+}
+
+func setManifestRef(annotations *map[string]string, ref string) {
+	if *annotations == nil {
+		*annotations = map[string]string{}
+	}
+	(*annotations)[RefAnnotation] = ref
+}
+
+// Manifests without a namespace get applied to the default namespace, so report them that way
+func defaultNamespace(namespace *string) {
+	if *namespace == "" {
+		*namespace = "default"
+	}
+}
@@ -0,0 +1,222 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// KubeContext is a context from the kubeconfig and the name of the cluster it points at
+type KubeContext struct {
+	Name    string
+	Cluster string
+}
+
+// SelectContexts returns the kubeconfig contexts to scan, sorted by name. If all is set that's every
+// context, otherwise it's the contexts matching any of the patterns, which can be names or globs like prod-*.
+func SelectContexts(options *pflag.FlagSet, all bool, patterns []string) ([]KubeContext, error) {
+	config, err := clientConfig(options, "").RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid context pattern %q: %w", pattern, err)
+		}
+	}
+	var selected []KubeContext
+	for name, context := range config.Contexts {
+		match := all
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				match = true
+			}
+		}
+		if match {
+			selected = append(selected, KubeContext{Name: name, Cluster: context.Cluster})
+		}
+	}
+	if len(selected) == 0 && all {
+		return nil, fmt.Errorf("kubeconfig has no contexts")
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no kubeconfig contexts matched %s", strings.Join(patterns, ","))
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected, nil
+}
+
+// ClusterScan holds the results of running a list of checks against one cluster.
+// Results are in the same order as the checks.
+type ClusterScan struct {
+	KubeContext
+	Results []Result
+}
+
+// ScanContext runs the checks against a single kubeconfig context, tagging every finding
+// with the cluster and context it came from
+func ScanContext(kc KubeContext, checks []Check, options *pflag.FlagSet) ClusterScan {
+	snapshot := NewContextSnapshot(options, kc.Name)
+	scan := ClusterScan{KubeContext: kc}
+	for _, c := range checks {
+		result := groupFindings(c.Run(snapshot), options)
+		for i := range result.Findings {
+			result.Findings[i].Cluster = kc.Cluster
+			result.Findings[i].Context = kc.Name
+		}
+		scan.Results = append(scan.Results, result)
+	}
+	return scan
+}
+
+// ClusterSummary is the per-cluster part of a multi-cluster report. Findings counts the
+// findings from every check that had any, Errors has the checks that couldn't complete.
+type ClusterSummary struct {
+	Context  string
+	Cluster  string
+	Total    int
+	Findings map[string]int    `json:",omitempty"`
+	Errors   map[string]string `json:",omitempty"`
+}
+
+// ClusterCheckResult is the result of one check against one cluster
+type ClusterCheckResult struct {
+	Check    string
+	Context  string
+	Cluster  string
+	Findings []Finding         `json:",omitempty"`
+	Bindings []Grant           `json:",omitempty"`
+	Items    []string          `json:",omitempty"`
+	Levels   *LevelReport      `json:",omitempty"`
+	PSA      []NamespacePSA    `json:",omitempty"`
+	Coverage []SeccompCoverage `json:",omitempty"`
+	Error    string            `json:",omitempty"`
+}
+
+// CommonFinding is a finding that turned up in more than one cluster, for example
+// the same privileged DaemonSet deployed everywhere
+type CommonFinding struct {
+	Check     string
+	Namespace string `json:",omitempty"`
+	Object    string
+	Container string `json:",omitempty"`
+	Detail    string `json:",omitempty"`
+	Contexts  []string
+}
+
+// MultiClusterReport combines the results of scanning several clusters
+type MultiClusterReport struct {
+	Clusters []ClusterSummary
+	Results  []ClusterCheckResult
+	Common   []CommonFinding
+}
+
+// NewMultiClusterReport builds the combined report for a multi-cluster scan. Results are
+// grouped by check, and any finding or binding seen in more than one cluster is listed in Common.
+func NewMultiClusterReport(checks []Check, scans []ClusterScan) MultiClusterReport {
+	var report MultiClusterReport
+	common := make(map[string]*CommonFinding)
+	var order []string
+	addCommon := func(cf CommonFinding, context string) {
+		key := strings.Join([]string{cf.Check, cf.Namespace, cf.Object, cf.Container, cf.Detail}, "|")
+		existing, ok := common[key]
+		if !ok {
+			existing = &cf
+			common[key] = existing
+			order = append(order, key)
+		}
+		for _, c := range existing.Contexts {
+			if c == context {
+				return
+			}
+		}
+		existing.Contexts = append(existing.Contexts, context)
+	}
+
+	for _, scan := range scans {
+		summary := ClusterSummary{Context: scan.Name, Cluster: scan.Cluster, Findings: map[string]int{}, Errors: map[string]string{}}
+		for i, r := range scan.Results {
+			title := checks[i].Title
+			if r.Err != nil {
+				summary.Errors[title] = r.Err.Error()
+				continue
+			}
+			count := len(r.Findings) + len(r.Bindings) + len(r.Items)
+			if r.Levels != nil {
+				count += len(r.Levels.violating())
+			}
+			for _, ns := range r.PSA {
+				if ns.Status() != "ok" {
+					count++
+				}
+			}
+			if count > 0 {
+				summary.Findings[title] = count
+				summary.Total += count
+			}
+		}
+		report.Clusters = append(report.Clusters, summary)
+	}
+
+	for i, c := range checks {
+		for _, scan := range scans {
+			r := scan.Results[i]
+			result := ClusterCheckResult{Check: c.Title, Context: scan.Name, Cluster: scan.Cluster, Findings: r.Findings, Bindings: r.Bindings, Items: r.Items, Levels: r.Levels, PSA: r.PSA, Coverage: r.Coverage}
+			if r.Err != nil {
+				result.Error = r.Err.Error()
+			}
+			report.Results = append(report.Results, result)
+			for _, f := range r.Findings {
+				addCommon(CommonFinding{Check: c.Title, Namespace: f.Namespace, Object: f.Workload, Container: f.Container, Detail: f.detail()}, scan.Name)
+			}
+			for _, b := range r.Bindings {
+				addCommon(CommonFinding{Check: c.Title, Namespace: b.Namespace, Object: b.Name}, scan.Name)
+			}
+		}
+	}
+
+	for _, key := range order {
+		if len(common[key].Contexts) > 1 {
+			report.Common = append(report.Common, *common[key])
+		}
+	}
+	sort.SliceStable(report.Common, func(i, j int) bool {
+		return len(report.Common[i].Contexts) > len(report.Common[j].Contexts)
+	})
+	return report
+}
+
+// detail is the check specific part of a finding, e.g. the capabilities added or the host path mounted
+func (f Finding) detail() string {
+	switch {
+	case f.Check == "Capability Risk":
+		return f.capabilityRisk()
+	case len(f.Capabilities) > 0:
+		return "capabilities " + strings.Join(f.Capabilities, ",")
+	case f.Hostport != 0:
+		return f.hostPort()
+	case f.VolumeType != "":
+		return f.volume()
+	case f.Path != "":
+		return "volume " + f.Volume + " : " + f.hostPath()
+	case f.Sysctl != "":
+		return "sysctl " + f.Sysctl
+	case f.Profile != "":
+		return "profile " + f.Profile
+	case f.Option != "":
+		return f.Option + " " + f.Value
+	case f.Reason != "":
+		return f.Reason
+	case f.SetAt != "":
+		return f.setAt()
+	}
+	return ""
+}
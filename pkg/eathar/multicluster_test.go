@@ -0,0 +1,94 @@
+package eathar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster: {server: https://prod.example.com}
+- name: dev
+  cluster: {server: https://dev.example.com}
+users:
+- name: admin
+  user: {token: test}
+contexts:
+- name: prod-eu
+  context: {cluster: prod, user: admin}
+- name: prod-us
+  context: {cluster: prod, user: admin}
+- name: dev
+  context: {cluster: dev, user: admin}
+`
+
+// kubeconfigOptions writes a kubeconfig and returns flags pointing at it
+func kubeconfigOptions(t *testing.T, kubeconfig string) *pflag.FlagSet {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	options := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	options.String("kubeconfig", path, "")
+	return options
+}
+
+func TestSelectContexts(t *testing.T) {
+	tests := []struct {
+		name     string
+		all      bool
+		patterns []string
+		want     []string
+	}{
+		{"all", true, nil, []string{"dev", "prod-eu", "prod-us"}},
+		{"glob", false, []string{"prod-*"}, []string{"prod-eu", "prod-us"}},
+		{"names", false, []string{"dev", "prod-us"}, []string{"dev", "prod-us"}},
+	}
+	options := kubeconfigOptions(t, testKubeconfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contexts, err := SelectContexts(options, tt.all, tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range contexts {
+				got = append(got, c.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("contexts = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("contexts = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectContextsErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubeconfig string
+		all        bool
+		patterns   []string
+		want       string
+	}{
+		{"no contexts", "apiVersion: v1\nkind: Config\n", true, nil, "kubeconfig has no contexts"},
+		{"no match", testKubeconfig, false, []string{"staging-*"}, "no kubeconfig contexts matched staging-*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SelectContexts(kubeconfigOptions(t, tt.kubeconfig), tt.all, tt.patterns)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
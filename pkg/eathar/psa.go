@@ -0,0 +1,132 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"sort"
+)
+
+// The namespace labels Pod Security Admission reads. Each mode has a matching -version label.
+const (
+	psaEnforceLabel = "pod-security.kubernetes.io/enforce"
+	psaAuditLabel   = "pod-security.kubernetes.io/audit"
+	psaWarnLabel    = "pod-security.kubernetes.io/warn"
+)
+
+// NamespacePSA is one row of the Pod Security Admission migration table. It has the namespace's
+// current labels, the highest enforce level its pods would allow and the pods that would be
+// rejected if enforcement were raised to baseline or restricted.
+type NamespacePSA struct {
+	Namespace      string
+	Enforce        string `json:",omitempty"`
+	EnforceVersion string `json:",omitempty"`
+	Audit          string `json:",omitempty"`
+	AuditVersion   string `json:",omitempty"`
+	Warn           string `json:",omitempty"`
+	WarnVersion    string `json:",omitempty"`
+	Suggested      string
+	// BreakBaseline and BreakRestricted are the pods (and pod templates) that would be rejected at each level
+	BreakBaseline   []string `json:",omitempty"`
+	BreakRestricted []string `json:",omitempty"`
+	// ViolatingEnforce are pods that don't meet the current enforce level, most likely
+	// because they were admitted before the label was set
+	ViolatingEnforce []string `json:",omitempty"`
+}
+
+// Status is a short description of what, if anything, needs doing for the namespace
+func (n NamespacePSA) Status() string {
+	switch {
+	case n.ViolatingEnforce != nil:
+		return "pods violate the enforced level"
+	case n.Enforce == "" && n.Suggested == LevelPrivileged:
+		return "no enforcement, pods need changes before any level can be enforced"
+	case n.Enforce == "":
+		return "no enforcement, can enforce " + n.Suggested
+	case levelRank(n.Suggested) > levelRank(n.Enforce):
+		return "can raise enforcement to " + n.Suggested
+	}
+	return "ok"
+}
+
+// levelRank orders the levels from least to most restrictive. No level at all ranks below privileged.
+func levelRank(level string) int {
+	switch level {
+	case LevelPrivileged:
+		return 0
+	case LevelBaseline:
+		return 1
+	case LevelRestricted:
+		return 2
+	}
+	return -1
+}
+
+// PSAAudit reads the Pod Security Admission labels on every namespace and works out the highest
+// enforce level each namespace could move to without its current pods being rejected
+func PSAAudit(s *Snapshot) ([]NamespacePSA, error) {
+	namespaces, err := s.Namespaces()
+	if err != nil {
+		return nil, err
+	}
+	levels, err := PSSLevels(s)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := s.scope()
+	if err != nil {
+		return nil, err
+	}
+	rows := make(map[string]*NamespacePSA)
+	for _, ns := range namespaces {
+		if !sc.includesNamespace(ns.Name) {
+			continue
+		}
+		rows[ns.Name] = &NamespacePSA{
+			Namespace:      ns.Name,
+			Enforce:        ns.Labels[psaEnforceLabel],
+			EnforceVersion: ns.Labels[psaEnforceLabel+"-version"],
+			Audit:          ns.Labels[psaAuditLabel],
+			AuditVersion:   ns.Labels[psaAuditLabel+"-version"],
+			Warn:           ns.Labels[psaWarnLabel],
+			WarnVersion:    ns.Labels[psaWarnLabel+"-version"],
+		}
+	}
+	for _, p := range levels.Pods {
+		row, ok := rows[p.Namespace]
+		if !ok {
+			// Manifests don't always include their namespaces, treat those as unlabelled
+			row = &NamespacePSA{Namespace: p.Namespace}
+			rows[p.Namespace] = row
+		}
+		if p.Level == LevelPrivileged {
+			row.BreakBaseline = append(row.BreakBaseline, p.object())
+		}
+		if p.Level != LevelRestricted {
+			row.BreakRestricted = append(row.BreakRestricted, p.object())
+		}
+		if row.Enforce != "" && levelRank(p.Level) < levelRank(row.Enforce) {
+			row.ViolatingEnforce = append(row.ViolatingEnforce, p.object())
+		}
+	}
+	var audit []NamespacePSA
+	for _, row := range rows {
+		switch {
+		case row.BreakBaseline != nil:
+			row.Suggested = LevelPrivileged
+		case row.BreakRestricted != nil:
+			row.Suggested = LevelBaseline
+		default:
+			row.Suggested = LevelRestricted
+		}
+		// Never suggest lowering a level that's already enforced
+		if levelRank(row.Enforce) > levelRank(row.Suggested) {
+			row.Suggested = row.Enforce
+		}
+		audit = append(audit, *row)
+	}
+	sort.Slice(audit, func(i, j int) bool { return audit[i].Namespace < audit[j].Namespace })
+	return audit, nil
+}
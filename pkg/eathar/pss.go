@@ -5,421 +5,765 @@ Copyright © 2022 Rory McCune <rorym@mccune.org.uk>
 
 */
 import (
-	"strings"
+	"path"
+	"sort"
 
-	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
 )
 
 //This needs to be exported to work with the JSON marshalling
 // omitempty thing is there as container won't always be relevant (e.g. hostPID)
 type Finding struct {
-	Check        string
-	Namespace    string
-	Pod          string
-	Container    string   `json:",omitempty"`
-	Capabilities []string `json:",omitempty"`
-	Hostport     int      `json:",omitempty"`
-	Volume       string   `json:",omitempty"`
-	Path         string   `json:",omitempty"`
-	Sysctl       string   `json:",omitempty"`
-	Image        string   `json:",omitempty"`
+	Check         string
+	Namespace     string
+	Pod           string
+	Container     string   `json:",omitempty"`
+	Capabilities  []string `json:",omitempty"`
+	Dangerous     []string `json:",omitempty"`
+	NoDropAll     bool     `json:",omitempty"`
+	Hostport      int      `json:",omitempty"`
+	ContainerPort int      `json:",omitempty"`
+	Protocol      string   `json:",omitempty"`
+	HostIP        string   `json:",omitempty"`
+	PortName      string   `json:",omitempty"`
+	HostNetwork   bool     `json:",omitempty"`
+	SensitivePort string   `json:",omitempty"`
+	Volume        string   `json:",omitempty"`
+	VolumeType    string   `json:",omitempty"`
+	Reason        string   `json:",omitempty"`
+	Path          string   `json:",omitempty"`
+	PathType      string   `json:",omitempty"`
+	SubPath       string   `json:",omitempty"`
+	ReadOnly      bool     `json:",omitempty"`
+	Propagation   string   `json:",omitempty"`
+	Risk          string   `json:",omitempty"`
+	Sysctl        string   `json:",omitempty"`
+	Profile       string   `json:",omitempty"`
+	Option        string   `json:",omitempty"`
+	Value         string   `json:",omitempty"`
+	Image         string   `json:",omitempty"`
+	Kind          string   `json:",omitempty"`
+	Ref           string   `json:",omitempty"`
+	Workload      string   `json:",omitempty"`
+	BarePod       bool     `json:",omitempty"`
+	Source        string   `json:",omitempty"`
+	SetAt         string   `json:",omitempty"`
+	ContainerType string   `json:",omitempty"`
+	Replicas      int      `json:",omitempty"`
+	Cluster       string   `json:",omitempty"`
+	Context       string   `json:",omitempty"`
 }
 
-func Hostnet(options *pflag.FlagSet) []Finding {
+// Where a finding came from, a running pod or the pod template of a controller
+const (
+	SourcePod      = "pod"
+	SourceTemplate = "template"
+)
+
+// newFinding creates a finding for a pod target, filling in the fields every pod level check reports
+func newFinding(check string, pod PodTarget, container string) Finding {
+	source := SourcePod
+	if pod.Kind != "Pod" {
+		source = SourceTemplate
+	}
+	return Finding{Source: source, Check: check, Namespace: pod.Namespace, Pod: pod.Name, Container: container, Kind: pod.Kind, Ref: pod.Ref, Workload: pod.Workload, BarePod: pod.BarePod}
+}
+
+// newContainerFinding creates a finding for one container of a pod target
+func newContainerFinding(check string, pod PodTarget, container EffectiveContainer) Finding {
+	p := newFinding(check, pod, container.Name)
+	p.ContainerType = container.Type
+	return p
+}
+
+func Hostnet(s *Snapshot) ([]Finding, error) {
 	var hostnetcont []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
 
 		if pod.Spec.HostNetwork {
-			p := Finding{Check: "hostnet", Namespace: pod.Namespace, Pod: pod.Name}
+			p := newFinding("hostnet", pod, "")
 			hostnetcont = append(hostnetcont, p)
 		}
 	}
-	return hostnetcont
+	return hostnetcont, nil
 }
 
-func Hostpid(options *pflag.FlagSet) []Finding {
+func Hostpid(s *Snapshot) ([]Finding, error) {
 	var hostpidcont []Finding
-	pods := connectWithPods(options)
 
-	for _, pod := range pods.Items {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
 
 		if pod.Spec.HostPID {
-			p := Finding{Check: "hostpid", Namespace: pod.Namespace, Pod: pod.Name, Container: ""}
+			p := newFinding("hostpid", pod, "")
 			hostpidcont = append(hostpidcont, p)
 		}
 	}
-	return hostpidcont
+	return hostpidcont, nil
 
 }
 
-func Hostipc(options *pflag.FlagSet) []Finding {
+func Hostipc(s *Snapshot) ([]Finding, error) {
 	var hostipccont []Finding
-	pods := connectWithPods(options)
 
-	for _, pod := range pods.Items {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
 
 		if pod.Spec.HostIPC {
-			p := Finding{Check: "hostipc", Namespace: pod.Namespace, Pod: pod.Name, Container: ""}
+			p := newFinding("hostipc", pod, "")
 			hostipccont = append(hostipccont, p)
 		}
 	}
-	return hostipccont
+	return hostipccont, nil
 }
 
-func HostProcess(options *pflag.FlagSet) []Finding {
+func HostProcess(s *Snapshot) ([]Finding, error) {
 	var hostprocesscont []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
-		hostProcessPod := pod.Spec.SecurityContext.WindowsOptions != nil && *pod.Spec.SecurityContext.WindowsOptions.HostProcess
-		if hostProcessPod {
-			p := Finding{Check: "HostProcess", Namespace: pod.Namespace, Pod: pod.Name}
-			hostprocesscont = append(hostprocesscont, p)
-		}
-		for _, container := range pod.Spec.Containers {
-			hostProcessCont := container.SecurityContext != nil && container.SecurityContext.WindowsOptions != nil && *container.SecurityContext.WindowsOptions.HostProcess
-			if hostProcessCont {
-				p := Finding{Check: "HostProcess", Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name}
-				hostprocesscont = append(hostprocesscont, p)
-			}
-		}
-		for _, init_container := range pod.Spec.InitContainers {
-			hostProcessCont := init_container.SecurityContext != nil && init_container.SecurityContext.WindowsOptions != nil && *init_container.SecurityContext.WindowsOptions.HostProcess
-			if hostProcessCont {
-				p := Finding{Check: "HostProcess", Namespace: pod.Namespace, Pod: pod.Name, Container: init_container.Name}
-				hostprocesscont = append(hostprocesscont, p)
-			}
-		}
-		for _, eph_container := range pod.Spec.EphemeralContainers {
-			hostProcessCont := eph_container.SecurityContext != nil && eph_container.SecurityContext.WindowsOptions != nil && *eph_container.SecurityContext.WindowsOptions.HostProcess
-			if hostProcessCont {
-				p := Finding{Check: "HostProcess", Namespace: pod.Namespace, Pod: pod.Name, Container: eph_container.Name}
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		// hostProcess set on the pod applies to every container that doesn't override it
+		for _, container := range EffectiveContainers(pod) {
+			if container.HostProcess.IsSet() && *container.HostProcess.Value {
+				p := newContainerFinding("HostProcess", pod, container)
+				p.SetAt = container.HostProcess.SetAt
 				hostprocesscont = append(hostprocesscont, p)
 			}
 		}
 	}
-	return hostprocesscont
+	return hostprocesscont, nil
 
 }
 
-func AllowPrivEsc(options *pflag.FlagSet) []Finding {
+func AllowPrivEsc(s *Snapshot) ([]Finding, error) {
 	var allowprivesccont []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
-		for _, container := range pod.Spec.Containers {
-			// Logic here is if there's no security context, or there is a security context and no mention of allow privilege escalation then the default is true
-			// We don't catch the case of someone explicitly setting it to true, but that seems unlikely
-			allowPrivilegeEscalation := (container.SecurityContext == nil) || (container.SecurityContext != nil && container.SecurityContext.AllowPrivilegeEscalation == nil)
-			if allowPrivilegeEscalation {
-				p := Finding{Check: "allowprivesc", Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name}
-				allowprivesccont = append(allowprivesccont, p)
-			}
-		}
-		for _, init_container := range pod.Spec.InitContainers {
-			allowPrivilegeEscalation := (init_container.SecurityContext == nil) || (init_container.SecurityContext != nil && init_container.SecurityContext.AllowPrivilegeEscalation == nil)
-			if allowPrivilegeEscalation {
-				p := Finding{Check: "allowprivesc", Namespace: pod.Namespace, Pod: pod.Name, Container: init_container.Name}
-				allowprivesccont = append(allowprivesccont, p)
-			}
-		}
-		for _, eph_container := range pod.Spec.EphemeralContainers {
-			allowPrivilegeEscalation := (eph_container.SecurityContext == nil) || (eph_container.SecurityContext != nil && eph_container.SecurityContext.AllowPrivilegeEscalation == nil)
-			if allowPrivilegeEscalation {
-				p := Finding{Check: "allowprivesc", Namespace: pod.Namespace, Pod: pod.Name, Container: eph_container.Name}
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			// If allowPrivilegeEscalation isn't set the default is true
+			if !container.AllowPrivilegeEscalation.IsSet() || *container.AllowPrivilegeEscalation.Value {
+				p := newContainerFinding("allowprivesc", pod, container)
+				p.SetAt = container.AllowPrivilegeEscalation.SetAt
 				allowprivesccont = append(allowprivesccont, p)
 			}
 		}
 	}
-	return allowprivesccont
+	return allowprivesccont, nil
 
 }
 
-func Privileged(options *pflag.FlagSet) []Finding {
+func Privileged(s *Snapshot) ([]Finding, error) {
 	var privcont []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
-		for _, container := range pod.Spec.Containers {
-			privileged_container := container.SecurityContext != nil && container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged
-			if privileged_container {
-				p := Finding{Check: "privileged", Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name}
-				privcont = append(privcont, p)
-			}
-		}
-		for _, init_container := range pod.Spec.InitContainers {
-			privileged_container := init_container.SecurityContext != nil && init_container.SecurityContext.Privileged != nil && *init_container.SecurityContext.Privileged
-			if privileged_container {
-				p := Finding{Check: "privileged", Namespace: pod.Namespace, Pod: pod.Name, Container: init_container.Name}
-				privcont = append(privcont, p)
-			}
-		}
-		for _, eph_container := range pod.Spec.EphemeralContainers {
-			privileged_container := eph_container.SecurityContext != nil && eph_container.SecurityContext.Privileged != nil && *eph_container.SecurityContext.Privileged
-			if privileged_container {
-				p := Finding{Check: "privileged", Namespace: pod.Namespace, Pod: pod.Name, Container: eph_container.Name}
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.Privileged.IsSet() && *container.Privileged.Value {
+				p := newContainerFinding("privileged", pod, container)
 				privcont = append(privcont, p)
 			}
 		}
 	}
-	return privcont
+	return privcont, nil
 
 }
 
-func AddedCapabilities(options *pflag.FlagSet) []Finding {
+func AddedCapabilities(s *Snapshot) ([]Finding, error) {
 	var capadded []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
-		for _, container := range pod.Spec.Containers {
-			cap_added := container.SecurityContext != nil && container.SecurityContext.Capabilities != nil && container.SecurityContext.Capabilities.Add != nil
-			if cap_added {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.Capabilities.IsSet() && container.Capabilities.Value.Add != nil {
 				//Need to convert the capabilities struct to strings.
 				var added_caps []string
-				for _, cap := range container.SecurityContext.Capabilities.Add {
+				for _, cap := range container.Capabilities.Value.Add {
 					added_caps = append(added_caps, string(cap))
 				}
-				p := Finding{Check: "Added Capabilities", Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name, Capabilities: added_caps}
-				capadded = append(capadded, p)
-			}
-		}
-
-		for _, init_container := range pod.Spec.InitContainers {
-			cap_added := init_container.SecurityContext != nil && init_container.SecurityContext.Capabilities != nil && init_container.SecurityContext.Capabilities.Add != nil
-			if cap_added {
-				var added_caps []string
-				for _, cap := range init_container.SecurityContext.Capabilities.Add {
-					added_caps = append(added_caps, string(cap))
-				}
-				p := Finding{Check: "Added Capabilities", Namespace: pod.Namespace, Pod: pod.Name, Container: init_container.Name, Capabilities: added_caps}
-				capadded = append(capadded, p)
-			}
-		}
-
-		for _, eph_container := range pod.Spec.EphemeralContainers {
-			cap_added := eph_container.SecurityContext != nil && eph_container.SecurityContext.Capabilities != nil && eph_container.SecurityContext.Capabilities.Add != nil
-			if cap_added {
-				var added_caps []string
-				for _, cap := range eph_container.SecurityContext.Capabilities.Add {
-					added_caps = append(added_caps, string(cap))
-				}
-				p := Finding{Check: "Added Capabilities", Namespace: pod.Namespace, Pod: pod.Name, Container: eph_container.Name, Capabilities: added_caps}
+				p := newContainerFinding("Added Capabilities", pod, container)
+				p.Capabilities = added_caps
 				capadded = append(capadded, p)
 			}
 		}
 	}
-	return capadded
+	return capadded, nil
 
 }
 
-func DroppedCapabilities(options *pflag.FlagSet) []Finding {
+func DroppedCapabilities(s *Snapshot) ([]Finding, error) {
 	var capdropped []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
-		for _, container := range pod.Spec.Containers {
-			cap_dropped := container.SecurityContext != nil && container.SecurityContext.Capabilities != nil && container.SecurityContext.Capabilities.Drop != nil
-			if cap_dropped {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.Capabilities.IsSet() && container.Capabilities.Value.Drop != nil {
 				var dropped_caps []string
-				for _, cap := range container.SecurityContext.Capabilities.Drop {
+				for _, cap := range container.Capabilities.Value.Drop {
 					dropped_caps = append(dropped_caps, string(cap))
 				}
-				p := Finding{Check: "Dropped Capabilities", Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name, Capabilities: dropped_caps}
+				p := newContainerFinding("Dropped Capabilities", pod, container)
+				p.Capabilities = dropped_caps
 				capdropped = append(capdropped, p)
 			}
 		}
+	}
+	return capdropped, nil
 
-		for _, init_container := range pod.Spec.InitContainers {
-			cap_dropped := init_container.SecurityContext != nil && init_container.SecurityContext.Capabilities != nil && init_container.SecurityContext.Capabilities.Drop != nil
-			if cap_dropped {
-				var dropped_caps []string
-				for _, cap := range init_container.SecurityContext.Capabilities.Drop {
-					dropped_caps = append(dropped_caps, string(cap))
-				}
-				p := Finding{Check: "Dropped Capabilities", Namespace: pod.Namespace, Pod: pod.Name, Container: init_container.Name, Capabilities: dropped_caps}
-				capdropped = append(capdropped, p)
-			}
-		}
+}
 
-		for _, eph_container := range pod.Spec.EphemeralContainers {
-			cap_dropped := eph_container.SecurityContext != nil && eph_container.SecurityContext.Capabilities != nil && eph_container.SecurityContext.Capabilities.Drop != nil
-			if cap_dropped {
-				var dropped_caps []string
-				for _, cap := range eph_container.SecurityContext.Capabilities.Drop {
-					dropped_caps = append(dropped_caps, string(cap))
+// CapabilityRisk works out each container's effective capabilities from the runtime's default set and
+// its adds and drops, and lists the containers that end up with dangerous capabilities or don't drop ALL
+func CapabilityRisk(s *Snapshot) ([]Finding, error) {
+	var risky []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		// Capabilities don't apply to Windows containers
+		if isWindows(pod.Spec) {
+			continue
+		}
+		for _, container := range EffectiveContainers(pod) {
+			privileged := container.Privileged.IsSet() && *container.Privileged.Value
+			effective := effectiveCapabilities(container.Capabilities.Value, privileged)
+			var dangerous []string
+			for _, c := range effective {
+				if contains(dangerousCapabilities, c) {
+					dangerous = append(dangerous, c)
 				}
-				p := Finding{Check: "Dropped Capabilities", Namespace: pod.Namespace, Pod: pod.Name, Container: eph_container.Name, Capabilities: dropped_caps}
-				capdropped = append(capdropped, p)
+			}
+			noDropAll := !dropsAll(container.Capabilities.Value)
+			if dangerous != nil || noDropAll {
+				p := newContainerFinding("Capability Risk", pod, container)
+				p.Capabilities = effective
+				p.Dangerous = dangerous
+				p.NoDropAll = noDropAll
+				risky = append(risky, p)
 			}
 		}
 	}
-	return capdropped
+	return risky, nil
+}
 
+// sensitivePorts are well known node ports, a pod listening on one of these on the node can take over
+// the service that normally uses it, or gets in the way of it
+var sensitivePorts = map[int]string{
+	22:    "SSH",
+	2375:  "Docker API",
+	2376:  "Docker API (TLS)",
+	2379:  "etcd client",
+	2380:  "etcd peer",
+	4194:  "cAdvisor",
+	6443:  "Kubernetes API server",
+	10249: "kube-proxy metrics",
+	10250: "kubelet API",
+	10255: "kubelet read-only API",
+	10256: "kube-proxy health",
+	10257: "kube-controller-manager",
+	10259: "kube-scheduler",
 }
 
-func HostPorts(options *pflag.FlagSet) []Finding {
+// HostPorts lists the ports containers expose on the node, either through a hostPort or because the pod
+// uses the host's network, in which case every containerPort is opened on the node
+func HostPorts(s *Snapshot) ([]Finding, error) {
 	var hostports []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
-		for _, container := range pod.Spec.Containers {
-			//Does the container have ports specified
-			cports := container.Ports != nil
-			if cports {
-				for _, port := range container.Ports {
-					// Is the port a host port
-					if port.HostPort != 0 {
-						p := Finding{Check: "Host Ports", Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name, Hostport: int(port.HostPort)}
-						hostports = append(hostports, p)
-					}
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			for _, port := range container.Container.Ports {
+				hostPort := int(port.HostPort)
+				if pod.Spec.HostNetwork && hostPort == 0 {
+					// With host networking the API server defaults hostPort to containerPort, manifests won't have it set yet
+					hostPort = int(port.ContainerPort)
 				}
-			}
-		}
-		for _, init_container := range pod.Spec.InitContainers {
-			cports := init_container.Ports != nil
-			if cports {
-				for _, port := range init_container.Ports {
-					if port.HostPort != 0 {
-						p := Finding{Check: "Host Ports", Namespace: pod.Namespace, Pod: pod.Name, Container: init_container.Name, Hostport: int(port.HostPort)}
-						hostports = append(hostports, p)
+				// Is the port a host port
+				if hostPort != 0 {
+					p := newContainerFinding("Host Ports", pod, container)
+					p.Hostport = hostPort
+					p.ContainerPort = int(port.ContainerPort)
+					p.Protocol = string(port.Protocol)
+					if p.Protocol == "" {
+						p.Protocol = string(corev1.ProtocolTCP)
 					}
+					p.HostIP = port.HostIP
+					p.PortName = port.Name
+					p.HostNetwork = pod.Spec.HostNetwork
+					p.SensitivePort = sensitivePorts[hostPort]
+					hostports = append(hostports, p)
 				}
 			}
 		}
-		for _, eph_container := range pod.Spec.EphemeralContainers {
-			cports := eph_container.Ports != nil
-			if cports {
-				for _, port := range eph_container.Ports {
-					if port.HostPort != 0 {
-						p := Finding{Check: "Host Ports", Namespace: pod.Namespace, Pod: pod.Name, Container: eph_container.Name, Hostport: int(port.HostPort)}
-						hostports = append(hostports, p)
-					}
+	}
+	return hostports, nil
+
+}
+
+func Seccomp(s *Snapshot) ([]Finding, error) {
+	var seccomp []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	// A container is unconfined if its effective profile (its own, or the pod's if it doesn't set one)
+	// is missing or explicitly Unconfined. Profile is left empty when nothing is set.
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if !container.SeccompProfile.IsSet() || container.SeccompProfile.Value.Type == corev1.SeccompProfileTypeUnconfined {
+				p := newContainerFinding("Seccomp Disabled", pod, container)
+				if container.SeccompProfile.IsSet() {
+					p.Profile = string(container.SeccompProfile.Value.Type)
 				}
+				p.SetAt = container.SeccompProfile.SetAt
+				seccomp = append(seccomp, p)
 			}
 		}
 	}
-	return hostports
+	return seccomp, nil
 
 }
 
-func Seccomp(options *pflag.FlagSet) []Finding {
-	var seccomp []Finding
-	pods := connectWithPods(options)
-	// The logic here is that if the pod is unconfined & the container is unconfined, it's unconfined.
-	// In theory if all the containers in the pod are unconfined we could just mark it at pod level, but that's more complex :P
-	for _, pod := range pods.Items {
-		unconfined_pod := (pod.Spec.SecurityContext == nil) || (pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.SeccompProfile == nil) || (pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.SeccompProfile != nil && pod.Spec.SecurityContext.SeccompProfile.Type == "Unconfined")
-		if unconfined_pod {
-			//log.Printf("Pod name %s was unconfined at pod level", pod.Name)
-			for _, container := range pod.Spec.Containers {
-				unconfined_container := (container.SecurityContext == nil) || (container.SecurityContext != nil && container.SecurityContext.SeccompProfile == nil) || (container.SecurityContext != nil && container.SecurityContext.SeccompProfile != nil && container.SecurityContext.SeccompProfile.Type == "Unconfined")
-				if unconfined_container {
-					p := Finding{Check: "Seccomp Disabled", Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name}
-					seccomp = append(seccomp, p)
+// SeccompLocalhost lists containers using Localhost seccomp profiles and the path of the profile on the node
+func SeccompLocalhost(s *Snapshot) ([]Finding, error) {
+	var localhost []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.SeccompProfile.IsSet() && container.SeccompProfile.Value.Type == corev1.SeccompProfileTypeLocalhost {
+				p := newContainerFinding("Seccomp Localhost Profile", pod, container)
+				if container.SeccompProfile.Value.LocalhostProfile != nil {
+					p.Profile = *container.SeccompProfile.Value.LocalhostProfile
 				}
+				p.SetAt = container.SeccompProfile.SetAt
+				localhost = append(localhost, p)
 			}
-			for _, init_container := range pod.Spec.InitContainers {
-				unconfined_init_container := (init_container.SecurityContext == nil) || (init_container.SecurityContext != nil && init_container.SecurityContext.SeccompProfile == nil) || (init_container.SecurityContext != nil && init_container.SecurityContext.SeccompProfile != nil && init_container.SecurityContext.SeccompProfile.Type == "Unconfined")
-				if unconfined_init_container {
-					p := Finding{Check: "Seccomp Disabled", Namespace: pod.Namespace, Pod: pod.Name, Container: init_container.Name}
-					seccomp = append(seccomp, p)
-				}
+		}
+	}
+	return localhost, nil
+}
+
+// SeccompCoverage counts the containers in a namespace by the type of their effective seccomp profile
+type SeccompCoverage struct {
+	Namespace      string
+	Containers     int
+	RuntimeDefault int
+	Localhost      int
+	Unconfined     int
+	Unset          int
+}
+
+// SeccompCoverageSummary works out the seccomp coverage of each namespace, sorted by namespace
+func SeccompCoverageSummary(s *Snapshot) ([]SeccompCoverage, error) {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make(map[string]*SeccompCoverage)
+	for _, pod := range targets {
+		ns, ok := namespaces[pod.Namespace]
+		if !ok {
+			ns = &SeccompCoverage{Namespace: pod.Namespace}
+			namespaces[pod.Namespace] = ns
+		}
+		for _, container := range EffectiveContainers(pod) {
+			ns.Containers++
+			if !container.SeccompProfile.IsSet() {
+				ns.Unset++
+				continue
 			}
-			for _, eph_container := range pod.Spec.EphemeralContainers {
-				unconfined_eph_container := (eph_container.SecurityContext == nil) || (eph_container.SecurityContext != nil && eph_container.SecurityContext.SeccompProfile == nil) || (eph_container.SecurityContext != nil && eph_container.SecurityContext.SeccompProfile != nil && eph_container.SecurityContext.SeccompProfile.Type == "Unconfined")
-				if unconfined_eph_container {
-					p := Finding{Check: "Seccomp Disabled", Namespace: pod.Namespace, Pod: pod.Name, Container: eph_container.Name}
-					seccomp = append(seccomp, p)
-				}
+			switch container.SeccompProfile.Value.Type {
+			case corev1.SeccompProfileTypeRuntimeDefault:
+				ns.RuntimeDefault++
+			case corev1.SeccompProfileTypeLocalhost:
+				ns.Localhost++
+			default:
+				ns.Unconfined++
 			}
 		}
 	}
-	return seccomp
-
+	var coverage []SeccompCoverage
+	for _, ns := range namespaces {
+		coverage = append(coverage, *ns)
+	}
+	sort.Slice(coverage, func(i, j int) bool { return coverage[i].Namespace < coverage[j].Namespace })
+	return coverage, nil
 }
 
-func HostPath(options *pflag.FlagSet) []Finding {
+// HostPath lists each host path volume with every container that mounts it, and a risk class based on
+// whether the path is sensitive and whether the mount is writable. Volumes no container mounts are listed on their own.
+func HostPath(s *Snapshot) ([]Finding, error) {
 	var hostpath []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
-		host_path := pod.Spec.Volumes != nil
-		if host_path {
-			for _, vol := range pod.Spec.Volumes {
-				if vol.HostPath != nil {
-					p := Finding{Check: "Host Path", Namespace: pod.Namespace, Pod: pod.Name, Volume: vol.Name, Path: vol.HostPath.Path}
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	sensitive := sensitivePaths(s.options)
+	for _, pod := range targets {
+		for _, vol := range pod.Spec.Volumes {
+			if vol.HostPath == nil {
+				continue
+			}
+			var pathType string
+			if vol.HostPath.Type != nil {
+				pathType = string(*vol.HostPath.Type)
+			}
+			mounted := false
+			for _, container := range EffectiveContainers(pod) {
+				for _, mount := range container.Container.VolumeMounts {
+					if mount.Name != vol.Name {
+						continue
+					}
+					mounted = true
+					p := newContainerFinding("Host Path", pod, container)
+					p.Volume = vol.Name
+					p.Path = vol.HostPath.Path
+					p.PathType = pathType
+					p.SubPath = mount.SubPath
+					p.ReadOnly = mount.ReadOnly
+					if mount.MountPropagation != nil {
+						p.Propagation = string(*mount.MountPropagation)
+					}
+					// A subPath mounts a directory below the volume's path, so that's what gets classified
+					p.Risk = hostPathRisk(path.Join(vol.HostPath.Path, mount.SubPath), p.ReadOnly, p.Propagation, sensitive)
 					hostpath = append(hostpath, p)
 				}
 			}
+			if !mounted {
+				p := newFinding("Host Path", pod, "")
+				p.Volume = vol.Name
+				p.Path = vol.HostPath.Path
+				p.PathType = pathType
+				// No container can reach the volume yet, so it's classified as if it were mounted read only
+				p.Risk = hostPathRisk(vol.HostPath.Path, true, "", sensitive)
+				hostpath = append(hostpath, p)
+			}
 		}
 	}
-	return hostpath
+	return hostpath, nil
 
 }
 
-func Apparmor(options *pflag.FlagSet) []Finding {
+// VolumeTypes lists volumes of types the Restricted profile doesn't allow
+func VolumeTypes(s *Snapshot) ([]Finding, error) {
+	var volumes []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, vol := range pod.Spec.Volumes {
+			if t := volumeType(vol); !contains(restrictedVolumeTypes, t) {
+				p := newFinding("Restricted Volume Types", pod, "")
+				p.Volume = vol.Name
+				p.VolumeType = t
+				volumes = append(volumes, p)
+			}
+		}
+	}
+	return volumes, nil
+}
+
+// RiskyVolumes lists volumes of types that are risky in their own right, such as gitRepo and nfs, and inline
+// CSI volumes from drivers that aren't on the allowed list, with the reason each one is risky
+func RiskyVolumes(s *Snapshot) ([]Finding, error) {
+	var volumes []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	drivers := allowedCSIDrivers(s.options)
+	for _, pod := range targets {
+		for _, vol := range pod.Spec.Volumes {
+			t := volumeType(vol)
+			reason, risky := riskyVolumeReasons[t]
+			if vol.CSI != nil && !contains(drivers, vol.CSI.Driver) {
+				reason, risky = "inline volume from the "+vol.CSI.Driver+" driver, which isn't on the allowed list. Inline volumes skip the checks on PersistentVolumes", true
+			}
+			if risky {
+				p := newFinding("Risky Volumes", pod, "")
+				p.Volume = vol.Name
+				p.VolumeType = t
+				p.Reason = reason
+				volumes = append(volumes, p)
+			}
+		}
+	}
+	return volumes, nil
+}
+
+func Apparmor(s *Snapshot) ([]Finding, error) {
 	var apparmor []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
 		// Default should be apparmor is set (well it is for docker anyway), so we only care if it's explicitly set to unconfined
-		if pod.Annotations != nil {
-			for key, val := range pod.Annotations {
-				if val == "unconfined" && strings.Split(key, "/")[0] == "container.apparmor.security.beta.kubernetes.io" {
-					p := Finding{Check: "Apparmor Disabled", Namespace: pod.Namespace, Pod: pod.Name}
-					apparmor = append(apparmor, p)
-				}
+		for _, container := range EffectiveContainers(pod) {
+			if container.AppArmorProfile.IsSet() && container.AppArmorProfile.Value.Type == corev1.AppArmorProfileTypeUnconfined {
+				p := newContainerFinding("Apparmor Disabled", pod, container)
+				p.SetAt = container.AppArmorProfile.SetAt
+				apparmor = append(apparmor, p)
 			}
 		}
 	}
-	return apparmor
+	return apparmor, nil
 
 }
 
-func Procmount(options *pflag.FlagSet) []Finding {
-	var unmaskedproc []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
-		for _, container := range pod.Spec.Containers {
-			unmask := container.SecurityContext != nil && container.SecurityContext.ProcMount != nil && *container.SecurityContext.ProcMount == "Unmasked"
-			if unmask {
-				p := Finding{Check: "Unmasked procmount", Namespace: pod.Namespace, Pod: pod.Name, Container: container.Name}
-				unmaskedproc = append(unmaskedproc, p)
+// ApparmorLocalhost lists containers using Localhost AppArmor profiles, which have to be loaded on every node the pod can run on
+func ApparmorLocalhost(s *Snapshot) ([]Finding, error) {
+	var localhost []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.AppArmorProfile.IsSet() && container.AppArmorProfile.Value.Type == corev1.AppArmorProfileTypeLocalhost {
+				p := newContainerFinding("Apparmor Localhost Profile", pod, container)
+				if container.AppArmorProfile.Value.LocalhostProfile != nil {
+					p.Profile = *container.AppArmorProfile.Value.LocalhostProfile
+				}
+				p.SetAt = container.AppArmorProfile.SetAt
+				localhost = append(localhost, p)
 			}
 		}
-		for _, init_container := range pod.Spec.InitContainers {
-			unmask := init_container.SecurityContext != nil && init_container.SecurityContext.ProcMount != nil && *init_container.SecurityContext.ProcMount == "Unmasked"
-			if unmask {
-				p := Finding{Check: "Unmasked procmount", Namespace: pod.Namespace, Pod: pod.Name, Container: init_container.Name}
-				unmaskedproc = append(unmaskedproc, p)
+	}
+	return localhost, nil
+}
+
+// SELinux lists pods and containers whose seLinuxOptions use a type outside the set the Pod Security Standards allow,
+// or set a user or role, and pods that set seLinuxChangePolicy. Pod level options are reported against the pod even
+// when every container overrides them, as Pod Security Admission still rejects them, so containers are only
+// reported for options they set themselves.
+func SELinux(s *Snapshot) ([]Finding, error) {
+	var selinux []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		rules, err := s.pssRules(pod.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		// check adds a finding for each disallowed field of a set of options
+		check := func(opts *corev1.SELinuxOptions, finding func() Finding) {
+			if opts == nil {
+				return
+			}
+			add := func(option, value string) {
+				p := finding()
+				p.Option = option
+				p.Value = value
+				selinux = append(selinux, p)
+			}
+			if opts.Type != "" && !contains(rules.seLinuxTypes, opts.Type) {
+				add("type", opts.Type)
+			}
+			if opts.User != "" {
+				add("user", opts.User)
+			}
+			if opts.Role != "" {
+				add("role", opts.Role)
+			}
+		}
+		if sc := pod.Spec.SecurityContext; sc != nil {
+			check(sc.SELinuxOptions, func() Finding {
+				p := newFinding("SELinux Options", pod, "")
+				p.SetAt = SetAtPod
+				return p
+			})
+			// seLinuxChangePolicy can only be set at the pod level
+			if sc.SELinuxChangePolicy != nil {
+				p := newFinding("SELinux Options", pod, "")
+				p.Option = "seLinuxChangePolicy"
+				p.Value = string(*sc.SELinuxChangePolicy)
+				p.SetAt = SetAtPod
+				selinux = append(selinux, p)
 			}
 		}
-		for _, eph_container := range pod.Spec.EphemeralContainers {
-			unmask := eph_container.SecurityContext != nil && eph_container.SecurityContext.ProcMount != nil && *eph_container.SecurityContext.ProcMount == "Unmasked"
-			if unmask {
-				p := Finding{Check: "Unmasked procmount", Namespace: pod.Namespace, Pod: pod.Name, Container: eph_container.Name}
+		for _, container := range EffectiveContainers(pod) {
+			if !container.SELinuxOptions.IsSet() || container.SELinuxOptions.SetAt == SetAtPod {
+				continue
+			}
+			check(container.SELinuxOptions.Value, func() Finding {
+				p := newContainerFinding("SELinux Options", pod, container)
+				p.SetAt = container.SELinuxOptions.SetAt
+				return p
+			})
+		}
+	}
+	return selinux, nil
+}
+
+func Procmount(s *Snapshot) ([]Finding, error) {
+	var unmaskedproc []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.ProcMount.IsSet() && *container.ProcMount.Value == corev1.UnmaskedProcMount {
+				p := newContainerFinding("Unmasked procmount", pod, container)
 				unmaskedproc = append(unmaskedproc, p)
 			}
 		}
 	}
-	return unmaskedproc
+	return unmaskedproc, nil
 
 }
 
-func Sysctl(options *pflag.FlagSet) []Finding {
+func Sysctl(s *Snapshot) ([]Finding, error) {
 	var sysctls []Finding
-	pods := connectWithPods(options)
-	for _, pod := range pods.Items {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		// Which sysctls are safe depends on the Pod Security Standards version the namespace uses
+		rules, err := s.pssRules(pod.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		// Sysctls can only be set at the pod level
 		sysctl := pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.Sysctls != nil
 		if sysctl {
 			for _, sys := range pod.Spec.SecurityContext.Sysctls {
-				safe := []string{"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range", "net.ipv4.ip_unprivileged_port_start", "net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range"}
-				safe_sys := false
-				for _, s := range safe {
-					if sys.Name == s {
-						safe_sys = true
-					}
-				}
-				if !safe_sys {
-					p := Finding{Check: "Unsafe Sysctl", Namespace: pod.Namespace, Sysctl: sys.Name}
+				if !contains(rules.sysctls, sys.Name) {
+					p := newFinding("Unsafe Sysctl", pod, "")
+					p.Sysctl = sys.Name
 					sysctls = append(sysctls, p)
 				}
 			}
 		}
 	}
-	return sysctls
+	return sysctls, nil
 
 }
+
+// RunAsNonRoot lists containers where runAsNonRoot is missing or false once the pod and container levels are resolved
+func RunAsNonRoot(s *Snapshot) ([]Finding, error) {
+	var nonroot []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if !container.RunAsNonRoot.IsSet() || !*container.RunAsNonRoot.Value {
+				p := newContainerFinding("Run As Non-Root Not Set", pod, container)
+				p.SetAt = container.RunAsNonRoot.SetAt
+				nonroot = append(nonroot, p)
+			}
+		}
+	}
+	return nonroot, nil
+}
+
+// RunAsRootUser lists containers explicitly set to run as UID 0
+func RunAsRootUser(s *Snapshot) ([]Finding, error) {
+	var rootuser []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.RunAsUser.IsSet() && *container.RunAsUser.Value == 0 {
+				p := newContainerFinding("Run As Root User", pod, container)
+				p.SetAt = container.RunAsUser.SetAt
+				rootuser = append(rootuser, p)
+			}
+		}
+	}
+	return rootuser, nil
+}
+
+// RunAsRootGroup lists containers explicitly set to run with GID 0
+func RunAsRootGroup(s *Snapshot) ([]Finding, error) {
+	var rootgroup []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.RunAsGroup.IsSet() && *container.RunAsGroup.Value == 0 {
+				p := newContainerFinding("Run As Root Group", pod, container)
+				p.SetAt = container.RunAsGroup.SetAt
+				rootgroup = append(rootgroup, p)
+			}
+		}
+	}
+	return rootgroup, nil
+}
+
+// RootFSGroup lists pods with fsGroup set to 0. fsGroup can only be set at the pod level.
+func RootFSGroup(s *Snapshot) ([]Finding, error) {
+	var fsgroup []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		if pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.FSGroup != nil && *pod.Spec.SecurityContext.FSGroup == 0 {
+			p := newFinding("Root FSGroup", pod, "")
+			p.SetAt = SetAtPod
+			fsgroup = append(fsgroup, p)
+		}
+	}
+	return fsgroup, nil
+}
+
+// RootSupplementalGroups lists pods with 0 in their supplementalGroups. These can only be set at the pod level.
+func RootSupplementalGroups(s *Snapshot) ([]Finding, error) {
+	var supplemental []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		if pod.Spec.SecurityContext == nil {
+			continue
+		}
+		for _, group := range pod.Spec.SecurityContext.SupplementalGroups {
+			if group == 0 {
+				p := newFinding("Root Supplemental Group", pod, "")
+				p.SetAt = SetAtPod
+				supplemental = append(supplemental, p)
+				break
+			}
+		}
+	}
+	return supplemental, nil
+}
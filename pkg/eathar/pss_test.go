@@ -0,0 +1,61 @@
+package eathar
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// snapshotOf returns an offline snapshot holding just the given pods
+func snapshotOf(pods ...corev1.Pod) *Snapshot {
+	s := newOfflineSnapshot(pflag.NewFlagSet("test", pflag.ContinueOnError))
+	s.pods.Items = pods
+	return s
+}
+
+func TestSELinux(t *testing.T) {
+	podOptions := &corev1.SELinuxOptions{Type: "spc_t", User: "system_u", Role: "system_r"}
+	tests := []struct {
+		name      string
+		pod       *corev1.SELinuxOptions
+		container *corev1.SELinuxOptions
+		// want is container/option for each finding, with an empty container for the pod
+		want []string
+	}{
+		{"pod level inherited", podOptions, nil, []string{"/type", "/user", "/role"}},
+		{"pod level overridden by every container", podOptions, &corev1.SELinuxOptions{Type: "container_t"}, []string{"/type", "/user", "/role"}},
+		{"container level", nil, &corev1.SELinuxOptions{Type: "spc_t"}, []string{"app/type"}},
+		{"both levels", &corev1.SELinuxOptions{Role: "system_r"}, &corev1.SELinuxOptions{Type: "spc_t"}, []string{"/role", "app/type"}},
+		{"allowed type", &corev1.SELinuxOptions{Type: "container_t"}, &corev1.SELinuxOptions{Type: "container_init_t"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{SELinuxOptions: tt.pod},
+					Containers:      []corev1.Container{{Name: "app", SecurityContext: &corev1.SecurityContext{SELinuxOptions: tt.container}}},
+				},
+			}
+			findings, err := SELinux(snapshotOf(pod))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Container+"/"+f.Option)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("findings = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("findings = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
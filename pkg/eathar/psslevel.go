@@ -0,0 +1,556 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The Pod Security Standards levels, using the same names as the pod-security.kubernetes.io labels
+const (
+	LevelPrivileged = "privileged"
+	LevelBaseline   = "baseline"
+	LevelRestricted = "restricted"
+)
+
+// Violation is a Pod Security Standards control that a pod fails
+type Violation struct {
+	Control   string
+	Level     string
+	Container string `json:",omitempty"`
+	Detail    string
+}
+
+// PodLevel is the highest Pod Security Standards level a pod (or pod template) meets, and the controls it fails
+type PodLevel struct {
+	Namespace string
+	Pod       string
+	Kind      string
+	Ref       string `json:",omitempty"`
+	Workload  string `json:",omitempty"`
+	Source    string `json:",omitempty"`
+	// Version is the Pod Security Standards version the pod was evaluated against
+	Version    string
+	Level      string
+	Violations []Violation `json:",omitempty"`
+}
+
+// NamespaceLevel rolls the pod levels up per namespace. Level is the highest level every pod
+// and pod template in the namespace meets, i.e. the level the namespace could enforce today.
+type NamespaceLevel struct {
+	Namespace string
+	Version   string
+	Level     string
+	Pods      int
+	// FailBaseline and FailRestricted count the pods that don't meet each level
+	FailBaseline   int
+	FailRestricted int
+	// Templates, TemplatesFailBaseline and TemplatesFailRestricted count the pod templates separately,
+	// as a workload's template and its pods would otherwise be counted twice
+	Templates               int `json:",omitempty"`
+	TemplatesFailBaseline   int `json:",omitempty"`
+	TemplatesFailRestricted int `json:",omitempty"`
+}
+
+// LevelReport is the output of the Pod Security Standards level evaluator
+type LevelReport struct {
+	Namespaces []NamespaceLevel
+	Pods       []PodLevel
+}
+
+// pssControl is one of the Pod Security Standards controls. check returns a violation
+// for each way the pod (or each of its containers) fails the control under the given rules.
+// since is the version the control was added in, if it wasn't there from the start.
+type pssControl struct {
+	name  string
+	level string
+	since string
+	check func(pod PodTarget, rules pssRules) []Violation
+}
+
+// baselineCapabilities are the capabilities the baseline level allows containers to add
+var baselineCapabilities = []string{"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD", "NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT"}
+
+// restrictedVolumeTypes are the volume types the restricted level allows
+var restrictedVolumeTypes = []string{"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim", "projected", "secret"}
+
+var pssControls = []pssControl{
+	{name: "HostProcess", level: LevelBaseline, check: controlHostProcess},
+	{name: "Host Namespaces", level: LevelBaseline, check: controlHostNamespaces},
+	{name: "Privileged Containers", level: LevelBaseline, check: controlPrivileged},
+	{name: "Capabilities", level: LevelBaseline, check: controlBaselineCapabilities},
+	{name: "HostPath Volumes", level: LevelBaseline, check: controlHostPath},
+	{name: "Host Ports", level: LevelBaseline, check: controlHostPorts},
+	{name: "AppArmor", level: LevelBaseline, check: controlAppArmor},
+	{name: "SELinux", level: LevelBaseline, check: controlSELinux},
+	{name: "/proc Mount Type", level: LevelBaseline, check: controlProcMount},
+	{name: "Seccomp", level: LevelBaseline, check: controlBaselineSeccomp},
+	{name: "Sysctls", level: LevelBaseline, check: controlSysctls},
+	{name: "Host Probes / Lifecycle Hooks", level: LevelBaseline, since: "v1.34", check: controlProbeHost},
+	{name: "Volume Types", level: LevelRestricted, check: controlVolumeTypes},
+	{name: "Privilege Escalation", level: LevelRestricted, check: controlPrivilegeEscalation},
+	{name: "Running as Non-root", level: LevelRestricted, check: controlRunAsNonRoot},
+	{name: "Running as Non-root user", level: LevelRestricted, since: "v1.23", check: controlRunAsNonRootUser},
+	{name: "Seccomp", level: LevelRestricted, check: controlRestrictedSeccomp},
+	{name: "Capabilities", level: LevelRestricted, check: controlRestrictedCapabilities},
+}
+
+// PSSLevels evaluates every Baseline and Restricted control against each pod, then works out
+// the highest level each namespace would pass if it were enforced today
+func PSSLevels(s *Snapshot) (*LevelReport, error) {
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	report := &LevelReport{}
+	namespaces := make(map[string]*NamespaceLevel)
+	for _, pod := range targets {
+		rules, err := s.pssRules(pod.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		pl := evaluatePod(pod, rules)
+		report.Pods = append(report.Pods, pl)
+		ns, ok := namespaces[pod.Namespace]
+		if !ok {
+			ns = &NamespaceLevel{Namespace: pod.Namespace, Version: rules.Version, Level: LevelRestricted}
+			namespaces[pod.Namespace] = ns
+		}
+		count, failBaseline, failRestricted := &ns.Pods, &ns.FailBaseline, &ns.FailRestricted
+		if pl.Source == SourceTemplate {
+			count, failBaseline, failRestricted = &ns.Templates, &ns.TemplatesFailBaseline, &ns.TemplatesFailRestricted
+		}
+		*count++
+		switch pl.Level {
+		case LevelPrivileged:
+			*failBaseline++
+			*failRestricted++
+			ns.Level = LevelPrivileged
+		case LevelBaseline:
+			*failRestricted++
+			if ns.Level == LevelRestricted {
+				ns.Level = LevelBaseline
+			}
+		}
+	}
+	for _, ns := range namespaces {
+		report.Namespaces = append(report.Namespaces, *ns)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace })
+	return report, nil
+}
+
+// evaluatePod runs every control in the rules' version against a pod. A pod that fails any baseline
+// control is only privileged, one that passes baseline but fails a restricted control is baseline.
+func evaluatePod(pod PodTarget, rules pssRules) PodLevel {
+	pl := PodLevel{Namespace: pod.Namespace, Pod: pod.Name, Kind: pod.Kind, Ref: pod.Ref, Workload: pod.Workload, Source: SourcePod, Version: rules.Version, Level: LevelRestricted}
+	if pod.Kind != "Pod" {
+		pl.Source = SourceTemplate
+	}
+	for _, control := range pssControls {
+		if !rules.has(control.since) {
+			continue
+		}
+		for _, v := range control.check(pod, rules) {
+			v.Control = control.name
+			v.Level = control.level
+			pl.Violations = append(pl.Violations, v)
+			if control.level == LevelBaseline {
+				pl.Level = LevelPrivileged
+			} else if pl.Level == LevelRestricted {
+				pl.Level = LevelBaseline
+			}
+		}
+	}
+	return pl
+}
+
+// setAtDetail notes where an inherited setting came from in a violation's detail
+func setAtDetail(setAt string) string {
+	switch setAt {
+	case "":
+		return ""
+	case SetAtAnnotation:
+		return " (set by annotation)"
+	}
+	return " (set at " + setAt + ")"
+}
+
+// isWindows reports whether a pod is marked as a Windows pod, which some controls don't apply to
+func isWindows(spec corev1.PodSpec) bool {
+	return spec.OS != nil && spec.OS.Name == corev1.Windows
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+func controlHostProcess(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		if c.HostProcess.IsSet() && *c.HostProcess.Value {
+			violations = append(violations, Violation{Container: c.Name, Detail: "hostProcess=true" + setAtDetail(c.HostProcess.SetAt)})
+		}
+	}
+	return violations
+}
+
+func controlHostNamespaces(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	if pod.Spec.HostNetwork {
+		violations = append(violations, Violation{Detail: "hostNetwork=true"})
+	}
+	if pod.Spec.HostPID {
+		violations = append(violations, Violation{Detail: "hostPID=true"})
+	}
+	if pod.Spec.HostIPC {
+		violations = append(violations, Violation{Detail: "hostIPC=true"})
+	}
+	return violations
+}
+
+func controlPrivileged(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		if c.Privileged.IsSet() && *c.Privileged.Value {
+			violations = append(violations, Violation{Container: c.Name, Detail: "privileged=true"})
+		}
+	}
+	return violations
+}
+
+func controlBaselineCapabilities(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		if !c.Capabilities.IsSet() {
+			continue
+		}
+		var added []string
+		for _, capability := range c.Capabilities.Value.Add {
+			if !contains(baselineCapabilities, string(capability)) {
+				added = append(added, string(capability))
+			}
+		}
+		if added != nil {
+			violations = append(violations, Violation{Container: c.Name, Detail: "adds " + strings.Join(added, ",")})
+		}
+	}
+	return violations
+}
+
+func controlHostPath(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, v := range pod.Spec.Volumes {
+		if v.HostPath != nil {
+			violations = append(violations, Violation{Detail: fmt.Sprintf("volume %s mounts %s", v.Name, v.HostPath.Path)})
+		}
+	}
+	return violations
+}
+
+func controlHostPorts(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		for _, port := range c.Container.Ports {
+			if port.HostPort != 0 {
+				violations = append(violations, Violation{Container: c.Name, Detail: fmt.Sprintf("hostPort %d", port.HostPort)})
+			}
+		}
+	}
+	return violations
+}
+
+func controlAppArmor(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		// Before the appArmorProfile fields were checked only the annotation counted
+		if !rules.appArmorFields {
+			c.AppArmorProfile = Setting[corev1.AppArmorProfile]{}
+			if annotation, ok := pod.Annotations[apparmorAnnotationPrefix+c.Name]; ok {
+				profile := apparmorFromAnnotation(annotation)
+				c.AppArmorProfile = Setting[corev1.AppArmorProfile]{Value: &profile, SetAt: SetAtAnnotation}
+			}
+		}
+		if !c.AppArmorProfile.IsSet() {
+			continue
+		}
+		if profile := c.AppArmorProfile.Value.Type; profile != corev1.AppArmorProfileTypeRuntimeDefault && profile != corev1.AppArmorProfileTypeLocalhost {
+			violations = append(violations, Violation{Container: c.Name, Detail: "appArmorProfile " + string(profile) + setAtDetail(c.AppArmorProfile.SetAt)})
+		}
+	}
+	return violations
+}
+
+func checkSELinux(opts Setting[corev1.SELinuxOptions], container string, rules pssRules) []Violation {
+	if !opts.IsSet() {
+		return nil
+	}
+	var violations []Violation
+	where := setAtDetail(opts.SetAt)
+	if opts.Value.Type != "" && !contains(rules.seLinuxTypes, opts.Value.Type) {
+		violations = append(violations, Violation{Container: container, Detail: "seLinuxOptions.type " + opts.Value.Type + where})
+	}
+	if opts.Value.User != "" {
+		violations = append(violations, Violation{Container: container, Detail: "seLinuxOptions.user " + opts.Value.User + where})
+	}
+	if opts.Value.Role != "" {
+		violations = append(violations, Violation{Container: container, Detail: "seLinuxOptions.role " + opts.Value.Role + where})
+	}
+	return violations
+}
+
+func controlSELinux(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	// Pod Security Admission rejects disallowed pod level options even when every container overrides them
+	if sc := pod.Spec.SecurityContext; sc != nil {
+		violations = append(violations, checkSELinux(Setting[corev1.SELinuxOptions]{Value: sc.SELinuxOptions}, "", rules)...)
+	}
+	for _, c := range EffectiveContainers(pod) {
+		violations = append(violations, checkSELinux(c.SELinuxOptions, c.Name, rules)...)
+	}
+	return violations
+}
+
+// controlProbeHost checks the host field of probes and lifecycle hooks isn't set, as that lets the kubelet
+// be used to reach addresses the pod couldn't
+func controlProbeHost(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		handlers := map[string]*corev1.ProbeHandler{}
+		for name, probe := range map[string]*corev1.Probe{"livenessProbe": c.Container.LivenessProbe, "readinessProbe": c.Container.ReadinessProbe, "startupProbe": c.Container.StartupProbe} {
+			if probe != nil {
+				handlers[name] = &probe.ProbeHandler
+			}
+		}
+		if c.Container.Lifecycle != nil {
+			for name, hook := range map[string]*corev1.LifecycleHandler{"postStart": c.Container.Lifecycle.PostStart, "preStop": c.Container.Lifecycle.PreStop} {
+				if hook != nil {
+					handlers[name] = &corev1.ProbeHandler{HTTPGet: hook.HTTPGet, TCPSocket: hook.TCPSocket}
+				}
+			}
+		}
+		var names []string
+		for name := range handlers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			h := handlers[name]
+			if h.HTTPGet != nil && h.HTTPGet.Host != "" {
+				violations = append(violations, Violation{Container: c.Name, Detail: name + " httpGet.host " + h.HTTPGet.Host})
+			}
+			if h.TCPSocket != nil && h.TCPSocket.Host != "" {
+				violations = append(violations, Violation{Container: c.Name, Detail: name + " tcpSocket.host " + h.TCPSocket.Host})
+			}
+		}
+	}
+	return violations
+}
+
+func controlProcMount(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		if c.ProcMount.IsSet() && *c.ProcMount.Value != corev1.DefaultProcMount {
+			violations = append(violations, Violation{Container: c.Name, Detail: "procMount " + string(*c.ProcMount.Value)})
+		}
+	}
+	return violations
+}
+
+func controlBaselineSeccomp(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		violations = append(violations, Violation{Detail: "seccompProfile Unconfined"})
+	}
+	for _, c := range EffectiveContainers(pod) {
+		if c.SeccompProfile.IsSet() && c.SeccompProfile.Value.Type == corev1.SeccompProfileTypeUnconfined {
+			violations = append(violations, Violation{Container: c.Name, Detail: "seccompProfile Unconfined" + setAtDetail(c.SeccompProfile.SetAt)})
+		}
+	}
+	return violations
+}
+
+func controlSysctls(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	if pod.Spec.SecurityContext == nil {
+		return nil
+	}
+	for _, sysctl := range pod.Spec.SecurityContext.Sysctls {
+		if !contains(rules.sysctls, sysctl.Name) {
+			violations = append(violations, Violation{Detail: "sysctl " + sysctl.Name})
+		}
+	}
+	return violations
+}
+
+// volumeType returns the name of the volume source a volume uses, as it appears in the pod spec
+func volumeType(v corev1.Volume) string {
+	switch {
+	case v.ConfigMap != nil:
+		return "configMap"
+	case v.CSI != nil:
+		return "csi"
+	case v.DownwardAPI != nil:
+		return "downwardAPI"
+	case v.EmptyDir != nil:
+		return "emptyDir"
+	case v.Ephemeral != nil:
+		return "ephemeral"
+	case v.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim"
+	case v.Projected != nil:
+		return "projected"
+	case v.Secret != nil:
+		return "secret"
+	case v.HostPath != nil:
+		return "hostPath"
+	case v.NFS != nil:
+		return "nfs"
+	case v.ISCSI != nil:
+		return "iscsi"
+	case v.GitRepo != nil:
+		return "gitRepo"
+	case v.RBD != nil:
+		return "rbd"
+	case v.CephFS != nil:
+		return "cephfs"
+	case v.Glusterfs != nil:
+		return "glusterfs"
+	case v.FlexVolume != nil:
+		return "flexVolume"
+	case v.Cinder != nil:
+		return "cinder"
+	case v.FC != nil:
+		return "fc"
+	case v.AWSElasticBlockStore != nil:
+		return "awsElasticBlockStore"
+	case v.GCEPersistentDisk != nil:
+		return "gcePersistentDisk"
+	case v.AzureDisk != nil:
+		return "azureDisk"
+	case v.AzureFile != nil:
+		return "azureFile"
+	case v.VsphereVolume != nil:
+		return "vsphereVolume"
+	case v.Quobyte != nil:
+		return "quobyte"
+	case v.Flocker != nil:
+		return "flocker"
+	case v.PhotonPersistentDisk != nil:
+		return "photonPersistentDisk"
+	case v.PortworxVolume != nil:
+		return "portworxVolume"
+	case v.ScaleIO != nil:
+		return "scaleIO"
+	case v.StorageOS != nil:
+		return "storageos"
+	}
+	return "unknown"
+}
+
+func controlVolumeTypes(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	for _, v := range pod.Spec.Volumes {
+		if t := volumeType(v); !contains(restrictedVolumeTypes, t) {
+			violations = append(violations, Violation{Detail: fmt.Sprintf("volume %s is %s", v.Name, t)})
+		}
+	}
+	return violations
+}
+
+func controlPrivilegeEscalation(pod PodTarget, rules pssRules) []Violation {
+	if rules.exempt(pod) {
+		return nil
+	}
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		if !c.AllowPrivilegeEscalation.IsSet() || *c.AllowPrivilegeEscalation.Value {
+			violations = append(violations, Violation{Container: c.Name, Detail: "allowPrivilegeEscalation != false"})
+		}
+	}
+	return violations
+}
+
+func controlRunAsNonRoot(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	// Setting it to false at the pod level fails even if every container sets it to true
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot {
+		violations = append(violations, Violation{Detail: "runAsNonRoot=false"})
+	}
+	for _, c := range EffectiveContainers(pod) {
+		if !c.RunAsNonRoot.IsSet() || !*c.RunAsNonRoot.Value {
+			violations = append(violations, Violation{Container: c.Name, Detail: "runAsNonRoot != true" + setAtDetail(c.RunAsNonRoot.SetAt)})
+		}
+	}
+	return violations
+}
+
+func controlRunAsNonRootUser(pod PodTarget, rules pssRules) []Violation {
+	var violations []Violation
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		violations = append(violations, Violation{Detail: "runAsUser=0"})
+	}
+	for _, c := range EffectiveContainers(pod) {
+		if c.RunAsUser.IsSet() && *c.RunAsUser.Value == 0 {
+			violations = append(violations, Violation{Container: c.Name, Detail: "runAsUser=0" + setAtDetail(c.RunAsUser.SetAt)})
+		}
+	}
+	return violations
+}
+
+func controlRestrictedSeccomp(pod PodTarget, rules pssRules) []Violation {
+	if rules.exempt(pod) {
+		return nil
+	}
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		// Unconfined, at the pod or container level, is already reported by the baseline control
+		if !c.SeccompProfile.IsSet() || c.SeccompProfile.Value.Type == "" {
+			violations = append(violations, Violation{Container: c.Name, Detail: "seccompProfile not set to RuntimeDefault or Localhost"})
+		}
+	}
+	return violations
+}
+
+func controlRestrictedCapabilities(pod PodTarget, rules pssRules) []Violation {
+	if rules.exempt(pod) {
+		return nil
+	}
+	var violations []Violation
+	for _, c := range EffectiveContainers(pod) {
+		caps := c.Capabilities.Value
+		dropsAll := false
+		var added []string
+		if caps != nil {
+			for _, capability := range caps.Drop {
+				if capability == "ALL" {
+					dropsAll = true
+				}
+			}
+			for _, capability := range caps.Add {
+				if capability != "NET_BIND_SERVICE" {
+					added = append(added, string(capability))
+				}
+			}
+		}
+		if !dropsAll {
+			violations = append(violations, Violation{Container: c.Name, Detail: "doesn't drop ALL capabilities"})
+		}
+		if added != nil {
+			violations = append(violations, Violation{Container: c.Name, Detail: "adds " + strings.Join(added, ",")})
+		}
+	}
+	return violations
+}
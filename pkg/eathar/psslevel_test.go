@@ -0,0 +1,160 @@
+package eathar
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// restrictedPod returns a pod that meets the restricted level, for tests to break one control at a time
+func restrictedPod() PodTarget {
+	yes, no := true, false
+	return PodTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Kind:       "Pod",
+		Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   &yes,
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "nginx",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: &no,
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+			Volumes: []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+		},
+	}
+}
+
+func TestEvaluatePod(t *testing.T) {
+	yes, no := true, false
+	root, user := int64(0), int64(1000)
+	tests := []struct {
+		name   string
+		modify func(pod *PodTarget)
+		want   string
+	}{
+		{"meets restricted", func(pod *PodTarget) {}, LevelRestricted},
+		{"no security context is baseline", func(pod *PodTarget) {
+			pod.Spec.SecurityContext = nil
+			pod.Spec.Containers[0].SecurityContext = nil
+		}, LevelBaseline},
+		{"allowed privilege escalation is baseline", func(pod *PodTarget) {
+			pod.Spec.Containers[0].SecurityContext.AllowPrivilegeEscalation = &yes
+		}, LevelBaseline},
+		{"restricted volume type is baseline", func(pod *PodTarget) {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: "nfs", VolumeSource: corev1.VolumeSource{NFS: &corev1.NFSVolumeSource{Server: "nfs", Path: "/"}}})
+		}, LevelBaseline},
+		{"privileged container is privileged", func(pod *PodTarget) {
+			pod.Spec.Containers[0].SecurityContext.Privileged = &yes
+		}, LevelPrivileged},
+		{"host network is privileged", func(pod *PodTarget) {
+			pod.Spec.HostNetwork = true
+		}, LevelPrivileged},
+		{"host path is privileged", func(pod *PodTarget) {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: "root", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}})
+		}, LevelPrivileged},
+		{"privileged init container is privileged", func(pod *PodTarget) {
+			pod.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "busybox", SecurityContext: &corev1.SecurityContext{Privileged: &yes}}}
+		}, LevelPrivileged},
+		{"pod runAsUser 0 overridden by every container is baseline", func(pod *PodTarget) {
+			pod.Spec.SecurityContext.RunAsUser = &root
+			pod.Spec.Containers[0].SecurityContext.RunAsUser = &user
+		}, LevelBaseline},
+		{"pod runAsNonRoot false overridden by every container is baseline", func(pod *PodTarget) {
+			pod.Spec.SecurityContext.RunAsNonRoot = &no
+			pod.Spec.Containers[0].SecurityContext.RunAsNonRoot = &yes
+		}, LevelBaseline},
+		{"pod unconfined seccomp overridden by every container is privileged", func(pod *PodTarget) {
+			pod.Spec.SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}
+			pod.Spec.Containers[0].SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+		}, LevelPrivileged},
+		{"pod SELinux type overridden by every container is privileged", func(pod *PodTarget) {
+			pod.Spec.SecurityContext.SELinuxOptions = &corev1.SELinuxOptions{Type: "spc_t"}
+			pod.Spec.Containers[0].SecurityContext.SELinuxOptions = &corev1.SELinuxOptions{Type: "container_t"}
+		}, LevelPrivileged},
+		{"pod SELinux user overridden by every container is privileged", func(pod *PodTarget) {
+			pod.Spec.SecurityContext.SELinuxOptions = &corev1.SELinuxOptions{User: "system_u"}
+			pod.Spec.Containers[0].SecurityContext.SELinuxOptions = &corev1.SELinuxOptions{Type: "container_t"}
+		}, LevelPrivileged},
+		{"pod SELinux role overridden by every container is privileged", func(pod *PodTarget) {
+			pod.Spec.SecurityContext.SELinuxOptions = &corev1.SELinuxOptions{Role: "system_r"}
+			pod.Spec.Containers[0].SecurityContext.SELinuxOptions = &corev1.SELinuxOptions{}
+		}, LevelPrivileged},
+	}
+	rules, err := rulesFor("latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := restrictedPod()
+			tt.modify(&pod)
+			pl := evaluatePod(pod, rules)
+			if pl.Level != tt.want {
+				t.Errorf("level = %s, want %s (violations %+v)", pl.Level, tt.want, pl.Violations)
+			}
+			if tt.want == LevelRestricted && len(pl.Violations) != 0 {
+				t.Errorf("restricted pod has violations %+v", pl.Violations)
+			}
+			if tt.want == LevelBaseline {
+				for _, v := range pl.Violations {
+					if v.Level != LevelRestricted {
+						t.Errorf("baseline pod fails %s control %s", v.Level, v.Control)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluatePodReportsPodLevelSetter(t *testing.T) {
+	root, user := int64(0), int64(1000)
+	pod := restrictedPod()
+	pod.Spec.SecurityContext.RunAsUser = &root
+	pod.Spec.Containers[0].SecurityContext.RunAsUser = &user
+	rules, err := rulesFor("latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl := evaluatePod(pod, rules)
+	if len(pl.Violations) != 1 {
+		t.Fatalf("got violations %+v, want one for the pod", pl.Violations)
+	}
+	if v := pl.Violations[0]; v.Container != "" || v.Control != "Running as Non-root user" {
+		t.Errorf("violation = %+v, want a pod level Running as Non-root user violation", v)
+	}
+}
+
+func TestPSSLevelsCountsTemplatesSeparately(t *testing.T) {
+	options := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	options.Bool("templates", true, "")
+	s := newOfflineSnapshot(options)
+	spec := corev1.PodSpec{HostPID: true, Containers: []corev1.Container{{Name: "app", Image: "nginx"}}}
+	s.deployments.Items = []appsv1.Deployment{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: spec}},
+	}}
+	s.pods.Items = []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}, Spec: spec},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "default"}, Spec: spec},
+	}
+	report, err := PSSLevels(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Namespaces) != 1 {
+		t.Fatalf("got namespaces %+v, want one", report.Namespaces)
+	}
+	want := NamespaceLevel{Namespace: "default", Version: latestPSSVersion, Level: LevelPrivileged, Pods: 2, FailBaseline: 2, FailRestricted: 2, Templates: 1, TemplatesFailBaseline: 1, TemplatesFailRestricted: 1}
+	if got := report.Namespaces[0]; got != want {
+		t.Errorf("namespace level = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// latestPSSVersion is the newest Pod Security Standards version eathar has rules for. Newer clusters are
+// evaluated against it, as are manifests when there's nothing to say which version to use.
+const latestPSSVersion = "v1.34"
+
+// pssRules are the Pod Security Standards as they were at one Kubernetes minor version
+type pssRules struct {
+	Version string
+	// sysctls are the sysctls the baseline level allows
+	sysctls []string
+	// seLinuxTypes are the SELinux types the baseline level allows, as well as leaving it unset
+	seLinuxTypes []string
+	// appArmorFields is set once the appArmorProfile fields are checked, before that only the annotations were
+	appArmorFields bool
+	// windowsExempt is set once Windows pods are exempt from the Linux only restricted controls
+	windowsExempt bool
+	// version is Version parsed, for comparing against the versions controls were added in
+	version *version.Version
+}
+
+// pssRuleChange is a change to the Pod Security Standards, applying from its version on
+type pssRuleChange struct {
+	since          string
+	sysctls        []string
+	seLinuxTypes   []string
+	appArmorFields bool
+	windowsExempt  bool
+}
+
+// pssRuleChanges are the changes to the rule data in the order they happened. Controls that were added
+// later have their own since version in pssControls.
+var pssRuleChanges = []pssRuleChange{
+	{
+		since:        "v1.0",
+		sysctls:      []string{"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range", "net.ipv4.ip_unprivileged_port_start", "net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range"},
+		seLinuxTypes: []string{"container_t", "container_init_t", "container_kvm_t"},
+	},
+	{since: "v1.25", windowsExempt: true},
+	{since: "v1.27", sysctls: []string{"net.ipv4.ip_local_reserved_ports"}},
+	{since: "v1.29", sysctls: []string{"net.ipv4.tcp_keepalive_time", "net.ipv4.tcp_fin_timeout", "net.ipv4.tcp_keepalive_intvl", "net.ipv4.tcp_keepalive_probes"}},
+	{since: "v1.30", appArmorFields: true},
+	{since: "v1.31", seLinuxTypes: []string{"container_engine_t"}},
+}
+
+// rulesFor builds the rules for a Kubernetes version, given as v1.29, 1.29 or latest.
+// Versions newer than the latest eathar knows about get the latest rules.
+func rulesFor(v string) (pssRules, error) {
+	if v == "" || v == "latest" {
+		v = latestPSSVersion
+	}
+	parsed, err := version.ParseGeneric(v)
+	if err != nil {
+		return pssRules{}, fmt.Errorf("invalid Pod Security Standards version %q: %w", v, err)
+	}
+	parsed = version.MajorMinor(parsed.Major(), parsed.Minor())
+	if latest := version.MustParseGeneric(latestPSSVersion); parsed.GreaterThan(latest) {
+		parsed = latest
+	}
+	rules := pssRules{Version: fmt.Sprintf("v%d.%d", parsed.Major(), parsed.Minor()), version: parsed}
+	for _, change := range pssRuleChanges {
+		if parsed.LessThan(version.MustParseGeneric(change.since)) {
+			break
+		}
+		rules.sysctls = append(rules.sysctls, change.sysctls...)
+		rules.seLinuxTypes = append(rules.seLinuxTypes, change.seLinuxTypes...)
+		rules.appArmorFields = rules.appArmorFields || change.appArmorFields
+		rules.windowsExempt = rules.windowsExempt || change.windowsExempt
+	}
+	return rules, nil
+}
+
+// has reports whether a control added in the since version is part of these rules
+func (r pssRules) has(since string) bool {
+	return since == "" || r.version.AtLeast(version.MustParseGeneric(since))
+}
+
+// exempt reports whether a Linux only restricted control is skipped for a pod
+func (r pssRules) exempt(pod PodTarget) bool {
+	return r.windowsExempt && isWindows(pod.Spec)
+}
+
+// pssRules returns the rules to evaluate the pods in a namespace against, the same way Pod Security
+// Admission would. --pss-version wins, then the namespace's enforce-version label, then the API
+// server's version. With none of them, e.g. for manifests, the latest rules are used.
+func (s *Snapshot) pssRules(namespace string) (pssRules, error) {
+	if rules, ok := s.pssRuleCache[namespace]; ok {
+		return rules, nil
+	}
+	v := s.pssVersion(namespace)
+	rules, err := rulesFor(v)
+	if err != nil {
+		return pssRules{}, err
+	}
+	if s.pssRuleCache == nil {
+		s.pssRuleCache = make(map[string]pssRules)
+	}
+	s.pssRuleCache[namespace] = rules
+	return rules, nil
+}
+
+// pssVersion picks the version to use for a namespace. Not being able to list namespaces or get the
+// server's version isn't fatal, it only means falling back to the next way of picking it.
+func (s *Snapshot) pssVersion(namespace string) string {
+	if v, _ := s.options.GetString("pss-version"); v != "" {
+		return v
+	}
+	if namespaces, err := s.Namespaces(); err == nil {
+		for _, ns := range namespaces {
+			if ns.Name != namespace {
+				continue
+			}
+			// A label that can't be parsed is ignored
+			if label := ns.Labels[psaEnforceLabel+"-version"]; label != "" {
+				if _, err := rulesFor(label); err == nil {
+					return label
+				}
+			}
+		}
+	}
+	if info, err := s.ServerVersion(); err == nil && info != nil {
+		return info.GitVersion
+	}
+	return latestPSSVersion
+}
@@ -0,0 +1,68 @@
+package eathar
+
+import "testing"
+
+func TestRulesFor(t *testing.T) {
+	tests := []struct {
+		version        string
+		want           string
+		sysctls        int
+		engineType     bool
+		windowsExempt  bool
+		appArmorFields bool
+		probeControl   bool
+		nonRootUser    bool
+	}{
+		{"v1.22", "v1.22", 5, false, false, false, false, false},
+		{"v1.24", "v1.24", 5, false, false, false, false, true},
+		{"v1.25", "v1.25", 5, false, true, false, false, true},
+		{"1.29", "v1.29", 10, false, true, false, false, true},
+		{"v1.30", "v1.30", 10, false, true, true, false, true},
+		{"v1.30.2", "v1.30", 10, false, true, true, false, true},
+		{"v1.31", "v1.31", 10, true, true, true, false, true},
+		{"v1.34", "v1.34", 10, true, true, true, true, true},
+		{"v1.40", latestPSSVersion, 10, true, true, true, true, true},
+		{"latest", latestPSSVersion, 10, true, true, true, true, true},
+		{"", latestPSSVersion, 10, true, true, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			rules, err := rulesFor(tt.version)
+			if err != nil {
+				t.Fatalf("rulesFor(%q) returned error: %v", tt.version, err)
+			}
+			if rules.Version != tt.want {
+				t.Errorf("Version = %s, want %s", rules.Version, tt.want)
+			}
+			if len(rules.sysctls) != tt.sysctls {
+				t.Errorf("got %d sysctls, want %d", len(rules.sysctls), tt.sysctls)
+			}
+			if got := contains(rules.seLinuxTypes, "container_engine_t"); got != tt.engineType {
+				t.Errorf("container_engine_t allowed = %v, want %v", got, tt.engineType)
+			}
+			if rules.windowsExempt != tt.windowsExempt {
+				t.Errorf("windowsExempt = %v, want %v", rules.windowsExempt, tt.windowsExempt)
+			}
+			if rules.appArmorFields != tt.appArmorFields {
+				t.Errorf("appArmorFields = %v, want %v", rules.appArmorFields, tt.appArmorFields)
+			}
+			if got := rules.has("v1.34"); got != tt.probeControl {
+				t.Errorf("has probe control = %v, want %v", got, tt.probeControl)
+			}
+			if got := rules.has("v1.23"); got != tt.nonRootUser {
+				t.Errorf("has non-root user control = %v, want %v", got, tt.nonRootUser)
+			}
+			if !rules.has("") {
+				t.Error("controls with no since version are missing")
+			}
+		})
+	}
+}
+
+func TestRulesForInvalid(t *testing.T) {
+	for _, v := range []string{"bogus", "v1.x", "newest"} {
+		if _, err := rulesFor(v); err == nil {
+			t.Errorf("rulesFor(%q) returned no error", v)
+		}
+	}
+}
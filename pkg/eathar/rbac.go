@@ -1,448 +1,236 @@
 package eathar
 
 import (
-	"context"
-
-	"github.com/rs/zerolog/log"
-	"github.com/spf13/pflag"
 	v1 "k8s.io/api/rbac/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func GetClusterAdminUsers(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
-	}
-	//Make a list of ClusterRoleBindings to return
-	var clusterAdminRoleBindingList v1.ClusterRoleBindingList
+// ScopeCluster is the scope of a grant made by a ClusterRoleBinding, a RoleBinding's scope is its namespace
+const ScopeCluster = "cluster-wide"
+
+// Grant is a binding an RBAC check found, giving its subjects a role. ClusterRoleBindings grant the role
+// across the cluster, RoleBindings only in their own namespace.
+type Grant struct {
+	// Kind is ClusterRoleBinding or RoleBinding
+	Kind string
+	Name string
+	// Namespace is only set for RoleBindings
+	Namespace string `json:",omitempty"`
+	// Scope is cluster-wide, or namespace followed by the RoleBinding's namespace
+	Scope    string
+	Subjects []v1.Subject
+	RoleRef  v1.RoleRef
+	// Ref is the manifest file and document the binding came from
+	Ref string `json:",omitempty"`
+}
 
-	// Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
+// clusterGrant makes the Grant for a ClusterRoleBinding
+func clusterGrant(binding v1.ClusterRoleBinding) Grant {
+	return Grant{
+		Kind:     "ClusterRoleBinding",
+		Name:     binding.Name,
+		Scope:    ScopeCluster,
+		Subjects: binding.Subjects,
+		RoleRef:  binding.RoleRef,
+		Ref:      binding.Annotations[RefAnnotation],
 	}
-	// Get all the ClusterRoles
-	//clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+}
 
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		//fmt.Println(clusterRoleBinding.Name)
-		//Get bindings for cluster-admin
-		if clusterRoleBinding.RoleRef.Name == "cluster-admin" {
-			//fmt.Printf("Binding name %s, Referenced role %s, Subjects %s\n", clusterRoleBinding.Name, clusterRoleBinding.RoleRef.Name, clusterRoleBinding.Subjects)
-			clusterAdminRoleBindingList.Items = append(clusterAdminRoleBindingList.Items, clusterRoleBinding)
-		}
+// roleGrant makes the Grant for a RoleBinding, which is scoped to its namespace
+func roleGrant(binding v1.RoleBinding) Grant {
+	return Grant{
+		Kind:      "RoleBinding",
+		Name:      binding.Name,
+		Namespace: binding.Namespace,
+		Scope:     "namespace " + binding.Namespace,
+		Subjects:  binding.Subjects,
+		RoleRef:   binding.RoleRef,
+		Ref:       binding.Annotations[RefAnnotation],
 	}
-	return clusterAdminRoleBindingList
-
 }
 
-func GetSecretsUsers(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
+// grants returns the bindings that give their subjects a role whose rules match. ClusterRoleBindings grant a
+// ClusterRole across the cluster. RoleBindings grant a Role, or a ClusterRole, only in their own namespace.
+// They can't give access to cluster scoped resources, so checks on those set namespaced to false and only
+// get ClusterRoleBindings.
+func grants(s *Snapshot, namespaced bool, matches func(rules []v1.PolicyRule) bool) ([]Grant, error) {
+	clusterRoles, err := s.ClusterRoles()
 	if err != nil {
-		log.Print(err)
+		return nil, err
 	}
-
-	var getSecretsClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	clusterRoleBindings, err := s.ClusterRoleBindings()
 	if err != nil {
-		log.Print(err)
+		return nil, err
 	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, resource := range policy.Resources {
-				//We include list here as listing secrets gives you the contents of the secret
-				if resource == "secrets" {
-					for _, verb := range policy.Verbs {
-						if verb == "get" || verb == "list" || verb == "*" {
-							getSecretsClusterRoles.Items = append(getSecretsClusterRoles.Items, clusterRole)
-							//We don't want to have this in multiple times if it lists multiple verbs
-							break
-						}
-					}
-				}
-			}
+	matchingClusterRoles := make(map[string]bool)
+	for _, clusterRole := range clusterRoles {
+		if matches(clusterRole.Rules) {
+			matchingClusterRoles[clusterRole.Name] = true
 		}
 	}
-	var getSecretsUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range getSecretsClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				getSecretsUsersList.Items = append(getSecretsUsersList.Items, clusterRoleBinding)
-			}
+	var grantList []Grant
+	for _, clusterRoleBinding := range clusterRoleBindings {
+		if matchingClusterRoles[clusterRoleBinding.RoleRef.Name] {
+			grantList = append(grantList, clusterGrant(clusterRoleBinding))
 		}
 	}
-	return getSecretsUsersList
-
-}
-
-func CreatePVUsers(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
+	if !namespaced {
+		return grantList, nil
+	}
+	roles, err := s.Roles()
 	if err != nil {
-		log.Print(err)
+		return nil, err
 	}
-
-	var createPVClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	roleBindings, err := s.RoleBindings()
 	if err != nil {
-		log.Print(err)
+		return nil, err
 	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, resource := range policy.Resources {
-				if resource == "persistentvolumes" {
-					for _, verb := range policy.Verbs {
-						if verb == "create" || verb == "*" {
-							createPVClusterRoles.Items = append(createPVClusterRoles.Items, clusterRole)
-							//We don't want to have this in multiple times if it lists multiple verbs
-							break
-						}
-					}
-				}
-			}
+	//Roles are keyed by namespace/name as a RoleBinding can only reference a Role in its own namespace
+	matchingRoles := make(map[string]bool)
+	for _, role := range roles {
+		if matches(role.Rules) {
+			matchingRoles[role.Namespace+"/"+role.Name] = true
 		}
 	}
-	var createPVUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range createPVClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				createPVUsersList.Items = append(createPVUsersList.Items, clusterRoleBinding)
+	for _, roleBinding := range roleBindings {
+		switch roleBinding.RoleRef.Kind {
+		case "ClusterRole":
+			if matchingClusterRoles[roleBinding.RoleRef.Name] {
+				grantList = append(grantList, roleGrant(roleBinding))
+			}
+		case "Role":
+			if matchingRoles[roleBinding.Namespace+"/"+roleBinding.RoleRef.Name] {
+				grantList = append(grantList, roleGrant(roleBinding))
 			}
 		}
 	}
-	return createPVUsersList
-
+	return grantList, nil
 }
 
-//Function to get a list of users with access to the escalate verb
-func EscalateUsers(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
-	}
-
-	var escalateClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	//TODO: This isn't quite right as it will also pick up users with access to the escalate verb on other resources
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
+// ruleGrants reports whether any rule allows one of the verbs on a resource
+func ruleGrants(rules []v1.PolicyRule, resource string, verbs ...string) bool {
+	for _, policy := range rules {
+		for _, r := range policy.Resources {
+			if r != resource {
+				continue
+			}
 			for _, verb := range policy.Verbs {
-				if verb == "escalate" {
-					escalateClusterRoles.Items = append(escalateClusterRoles.Items, clusterRole)
+				if contains(verbs, verb) {
+					return true
 				}
 			}
 		}
 	}
-	var escalateUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range escalateClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				escalateUsersList.Items = append(escalateUsersList.Items, clusterRoleBinding)
-			}
+	return false
+}
+
+// ruleHasVerb reports whether any rule allows a verb, whatever the resource
+func ruleHasVerb(rules []v1.PolicyRule, verb string) bool {
+	for _, policy := range rules {
+		if contains(policy.Verbs, verb) {
+			return true
 		}
 	}
-	return escalateUsersList
-
+	return false
 }
 
-//Function to list users with access to the impersonate verb
-func ImpersonateUsers(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
+func GetClusterAdminUsers(s *Snapshot) ([]Grant, error) {
+	clusterRoleBindings, err := s.ClusterRoleBindings()
 	if err != nil {
-		log.Print(err)
+		return nil, err
 	}
-
-	var impersonateClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	roleBindings, err := s.RoleBindings()
 	if err != nil {
-		log.Print(err)
+		return nil, err
 	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, verb := range policy.Verbs {
-				if verb == "impersonate" {
-					impersonateClusterRoles.Items = append(impersonateClusterRoles.Items, clusterRole)
-				}
-			}
+	//Make a list of bindings to return
+	var clusterAdminRoleBindingList []Grant
+
+	for _, clusterRoleBinding := range clusterRoleBindings {
+		//Get bindings for cluster-admin
+		if clusterRoleBinding.RoleRef.Name == "cluster-admin" {
+			clusterAdminRoleBindingList = append(clusterAdminRoleBindingList, clusterGrant(clusterRoleBinding))
 		}
 	}
-	var impersonateUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range impersonateClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				impersonateUsersList.Items = append(impersonateUsersList.Items, clusterRoleBinding)
-			}
+	//A RoleBinding to cluster-admin gives full control of everything in its namespace
+	for _, roleBinding := range roleBindings {
+		if roleBinding.RoleRef.Kind == "ClusterRole" && roleBinding.RoleRef.Name == "cluster-admin" {
+			clusterAdminRoleBindingList = append(clusterAdminRoleBindingList, roleGrant(roleBinding))
 		}
 	}
-	return impersonateUsersList
+	return clusterAdminRoleBindingList, nil
 
 }
 
-//Function to list users with access to the bind verb
-func BindUsers(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
-	}
+func GetSecretsUsers(s *Snapshot) ([]Grant, error) {
+	//We include list here as listing secrets gives you the contents of the secret
+	return grants(s, true, func(rules []v1.PolicyRule) bool {
+		return ruleGrants(rules, "secrets", "get", "list", "*")
+	})
+}
 
-	var bindClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, verb := range policy.Verbs {
-				if verb == "bind" {
-					bindClusterRoles.Items = append(bindClusterRoles.Items, clusterRole)
-				}
-			}
-		}
-	}
-	var bindUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range bindClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				bindUsersList.Items = append(bindUsersList.Items, clusterRoleBinding)
-			}
-		}
-	}
-	return bindUsersList
+func CreatePVUsers(s *Snapshot) ([]Grant, error) {
+	//persistentvolumes are cluster scoped so only ClusterRoleBindings can grant access to them
+	return grants(s, false, func(rules []v1.PolicyRule) bool {
+		return ruleGrants(rules, "persistentvolumes", "create", "*")
+	})
+}
 
+//Function to get a list of users with access to the escalate verb
+func EscalateUsers(s *Snapshot) ([]Grant, error) {
+	//TODO: This isn't quite right as it will also pick up users with access to the escalate verb on other resources
+	return grants(s, true, func(rules []v1.PolicyRule) bool {
+		return ruleHasVerb(rules, "escalate")
+	})
 }
 
-//Function to list users who can create or modify validatingadmissionwebhookconfigurations
-func ValidatingWebhookUsers(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
-	}
+//Function to list users with access to the impersonate verb
+func ImpersonateUsers(s *Snapshot) ([]Grant, error) {
+	//A RoleBinding can still let its subjects impersonate the service accounts in its namespace
+	return grants(s, true, func(rules []v1.PolicyRule) bool {
+		return ruleHasVerb(rules, "impersonate")
+	})
+}
 
-	var validatingWebhookClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, resource := range policy.Resources {
-				if resource == "validatingadmissionwebhookconfigurations" {
-					for _, verb := range policy.Verbs {
-						if verb == "create" || verb == "update" || verb == "patch" || verb == "delete" || verb == "*" {
-							validatingWebhookClusterRoles.Items = append(validatingWebhookClusterRoles.Items, clusterRole)
-							//We don't want to have this in multiple times if it lists multiple verbs
-							break
-						}
-					}
-				}
-			}
-		}
-	}
-	var validatingWebhookUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range validatingWebhookClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				validatingWebhookUsersList.Items = append(validatingWebhookUsersList.Items, clusterRoleBinding)
-			}
-		}
-	}
-	return validatingWebhookUsersList
+//Function to list users with access to the bind verb
+func BindUsers(s *Snapshot) ([]Grant, error) {
+	return grants(s, true, func(rules []v1.PolicyRule) bool {
+		return ruleHasVerb(rules, "bind")
+	})
 }
 
-//Function to list users who can create or modify mutatingadmissionwebhookconfigurations
-func MutatingWebhookUsers(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
-	}
-	var mutatingWebhookClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, resource := range policy.Resources {
-				if resource == "mutatingadmissionwebhookconfigurations" {
-					for _, verb := range policy.Verbs {
-						if verb == "create" || verb == "update" || verb == "patch" || verb == "delete" || verb == "*" {
-							mutatingWebhookClusterRoles.Items = append(mutatingWebhookClusterRoles.Items, clusterRole)
-							//We don't want to have this in multiple times if it lists multiple verbs
-							break
-						}
-					}
-				}
-			}
-		}
-	}
-	var mutatingWebhookUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range mutatingWebhookClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				mutatingWebhookUsersList.Items = append(mutatingWebhookUsersList.Items, clusterRoleBinding)
-			}
-		}
-	}
-	return mutatingWebhookUsersList
+//Function to list users who can create or modify validatingadmissionwebhookconfigurations
+func ValidatingWebhookUsers(s *Snapshot) ([]Grant, error) {
+	//Webhook configurations are cluster scoped so only ClusterRoleBindings can grant access to them
+	return grants(s, false, func(rules []v1.PolicyRule) bool {
+		return ruleGrants(rules, "validatingadmissionwebhookconfigurations", "create", "update", "patch", "delete", "*")
+	})
+}
 
+//Function to list users who can create or modify mutatingadmissionwebhookconfigurations
+func MutatingWebhookUsers(s *Snapshot) ([]Grant, error) {
+	return grants(s, false, func(rules []v1.PolicyRule) bool {
+		return ruleGrants(rules, "mutatingadmissionwebhookconfigurations", "create", "update", "patch", "delete", "*")
+	})
 }
 
-//This Function finds all clusterroles that allow wildcard access to all resources and the clusterrolebindings that are associated with them
-func WildcardAccess(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
-	}
-	var wildcardClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, resource := range policy.Resources {
-				if resource == "*" {
-					for _, verb := range policy.Verbs {
-						if verb == "*" {
-							wildcardClusterRoles.Items = append(wildcardClusterRoles.Items, clusterRole)
-						}
-					}
-				}
-			}
-		}
-	}
-	var wildcardUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range wildcardClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				wildcardUsersList.Items = append(wildcardUsersList.Items, clusterRoleBinding)
-			}
-		}
-	}
-	return wildcardUsersList
+//This Function finds all clusterroles and roles that allow wildcard access to all resources and the bindings that are associated with them
+func WildcardAccess(s *Snapshot) ([]Grant, error) {
+	return grants(s, true, func(rules []v1.PolicyRule) bool {
+		return ruleGrants(rules, "*", "*")
+	})
 }
 
-//This function finds all clusterroles that allow for create rights to the token sub-resource of serviceaccounts and the clusterrolebindings that are associated with them
-func CreateServiceAccountTokens(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
-	}
-	var createServiceAccountTokensClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, resource := range policy.Resources {
-				if resource == "serviceaccounts/token" {
-					for _, verb := range policy.Verbs {
-						if verb == "create" {
-							createServiceAccountTokensClusterRoles.Items = append(createServiceAccountTokensClusterRoles.Items, clusterRole)
-						}
-					}
-				}
-			}
-		}
-	}
-	var createServiceAccountTokensUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range createServiceAccountTokensClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				createServiceAccountTokensUsersList.Items = append(createServiceAccountTokensUsersList.Items, clusterRoleBinding)
-			}
-		}
-	}
-	return createServiceAccountTokensUsersList
+//This function finds all clusterroles and roles that allow for create rights to the token sub-resource of serviceaccounts and the bindings that are associated with them
+func CreateServiceAccountTokens(s *Snapshot) ([]Grant, error) {
+	return grants(s, true, func(rules []v1.PolicyRule) bool {
+		return ruleGrants(rules, "serviceaccounts/token", "create")
+	})
 }
 
 //This function finds all clusterroles that can update the approval sub-resource of certificatesigningrequests and the clusterrolebindings that are associated with them
-func UpdateCSRApproval(options *pflag.FlagSet) v1.ClusterRoleBindingList {
-	clientset, err := initKubeClient()
-	if err != nil {
-		log.Print(err)
-	}
-	var updateCSRApprovalClusterRoles v1.ClusterRoleList
-	clusterRoles, err := clientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRole := range clusterRoles.Items {
-		for _, policy := range clusterRole.Rules {
-			for _, resource := range policy.Resources {
-				if resource == "certificatesigningrequests/approval" {
-					for _, verb := range policy.Verbs {
-						if verb == "update" || verb == "*" {
-							updateCSRApprovalClusterRoles.Items = append(updateCSRApprovalClusterRoles.Items, clusterRole)
-							//We don't want to have this in multiple times if it lists multiple verbs
-							break
-						}
-					}
-				}
-			}
-		}
-	}
-	var updateCSRApprovalUsersList v1.ClusterRoleBindingList
-	//Get all the ClusterRoleBindings
-	clusterRoleBindings, err := clientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Print(err)
-	}
-	for _, clusterRoleBinding := range clusterRoleBindings.Items {
-		for _, clusterRole := range updateCSRApprovalClusterRoles.Items {
-			if clusterRoleBinding.RoleRef.Name == clusterRole.Name {
-				updateCSRApprovalUsersList.Items = append(updateCSRApprovalUsersList.Items, clusterRoleBinding)
-			}
-		}
-	}
-	return updateCSRApprovalUsersList
+func UpdateCSRApproval(s *Snapshot) ([]Grant, error) {
+	//certificatesigningrequests are cluster scoped so only ClusterRoleBindings can grant access to them
+	return grants(s, false, func(rules []v1.PolicyRule) bool {
+		return ruleGrants(rules, "certificatesigningrequests/approval", "update", "*")
+	})
 }
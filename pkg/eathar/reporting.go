@@ -8,12 +8,12 @@ Copyright © 2022 Rory McCune <rorym@mccune.org.uk>
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"os"
 	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/pflag"
-	v1 "k8s.io/api/rbac/v1"
 )
 
 var style string = ` <style>
@@ -98,7 +98,7 @@ func ReportPSS(f []Finding, options *pflag.FlagSet, check string) {
 		if f != nil {
 			fmt.Fprintln(rep, "<table>")
 			switch f[0].Check {
-			case "hostpid", "hostnet", "hostipc", "privileged", "allowprivesc", "HostProcess", "Seccomp Disabled", "Unmasked Procmount", "Apparmor Disabled":
+			case "hostpid", "hostnet", "hostipc", "privileged", "allowprivesc", "HostProcess", "Unmasked Procmount":
 				if f[0].Container != "" {
 					fmt.Fprintf(rep, "<tr><th>Namespace</th><th>Pod</th><th>Container</th></tr>")
 				} else {
@@ -109,30 +109,72 @@ func ReportPSS(f []Finding, options *pflag.FlagSet, check string) {
 			case "Dropped Capabilities":
 				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>dropped capabilities</th></tr>")
 			case "Host Ports":
-				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>port</th</tr>")
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>port</th><th>protocol</th><th>host IP</th><th>container port</th><th>name</th><th>host network</th><th>sensitive</th></tr>")
 			case "Host Path":
-				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>volume</th><th>path</th></tr>")
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>volume</th><th>path</th><th>type</th><th>subPath</th><th>access</th><th>propagation</th><th>risk</th></tr>")
 			case "Unsafe Sysctl":
 				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>unsafe sysctl</th></tr>")
+			case "Restricted Volume Types", "Risky Volumes":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>volume</th><th>type</th><th>reason</th></tr>")
+			case "Shared Process Namespace", "Host User Namespace", "Service Links":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th></tr>")
+			case "Host Aliases":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>hostname</th><th>IP</th></tr>")
+			case "DNS Policy":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>policy</th><th>detail</th></tr>")
+			case "Windows Admin User", "GMSA Credential Spec":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>option</th><th>value</th><th>set at</th></tr>")
+			case "Windows Node Selector", "Windows Host Network":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>windows pod because</th></tr>")
+			case "Apparmor Localhost Profile", "Seccomp Localhost Profile":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>profile</th><th>set at</th></tr>")
+			case "Capability Risk":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>effective capabilities</th><th>dangerous capabilities</th><th>drops ALL</th></tr>")
+			case "Seccomp Disabled":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>profile</th><th>set at</th></tr>")
+			case "SELinux Options":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>option</th><th>value</th><th>set at</th></tr>")
+			case "Apparmor Disabled", "Run As Non-Root Not Set", "Run As Root User", "Run As Root Group", "Root FSGroup", "Root Supplemental Group":
+				fmt.Fprintf(rep, "<tr><th>namespace</th><th>pod</th><th>container</th><th>set at</th></tr>")
 			}
 			for _, i := range f {
 				switch i.Check {
-				case "hostpid", "hostnet", "hostipc", "privileged", "allowprivesc", "HostProcess", "Seccomp Disabled", "Unmasked Procmount", "Apparmor Disabled":
+				case "hostpid", "hostnet", "hostipc", "privileged", "allowprivesc", "HostProcess", "Unmasked Procmount":
 					if i.Container != "" {
-						fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.Pod, i.Container)
+						fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container())
 					} else {
-						fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td></tr>", i.Namespace, i.Pod)
+						fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td></tr>", i.Namespace, i.object())
 					}
 				case "Added Capabilities":
-					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.Pod, i.Container, strings.Join(i.Capabilities[:], ","))
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), strings.Join(i.Capabilities[:], ","))
 				case "Dropped Capabilities":
-					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.Pod, i.Container, strings.Join(i.Capabilities[:], ","))
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), strings.Join(i.Capabilities[:], ","))
 				case "Host Ports":
-					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%d</td></tr>", i.Namespace, i.Pod, i.Container, i.Hostport)
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%d</td><td>%s</td><td>%s</td><td>%d</td><td>%s</td><td>%t</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), i.Hostport, i.Protocol, i.HostIP, i.ContainerPort, i.PortName, i.HostNetwork, i.SensitivePort)
 				case "Host Path":
-					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.Pod, i.Volume, i.Path)
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), i.Volume, i.Path, i.PathType, i.SubPath, i.access(), i.Propagation, i.Risk)
 				case "Unsafe Sysctl":
-					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.Pod, i.Sysctl)
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.Sysctl)
+				case "Restricted Volume Types", "Risky Volumes":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.Volume, i.VolumeType, html.EscapeString(i.Reason))
+				case "Shared Process Namespace", "Host User Namespace", "Service Links":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td></tr>", i.Namespace, i.object())
+				case "Host Aliases", "DNS Policy":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), html.EscapeString(i.Option), html.EscapeString(i.Value))
+				case "Windows Admin User", "GMSA Credential Spec":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), i.Option, html.EscapeString(i.Value), i.setAt())
+				case "Windows Node Selector", "Windows Host Network":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.Reason)
+				case "Apparmor Localhost Profile", "Seccomp Localhost Profile":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), html.EscapeString(i.Profile), i.setAt())
+				case "Capability Risk":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%t</td></tr>", i.Namespace, i.object(), i.container(), strings.Join(i.Capabilities, ","), strings.Join(i.Dangerous, ","), !i.NoDropAll)
+				case "Seccomp Disabled":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), i.Profile, i.setAt())
+				case "SELinux Options":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), i.Option, html.EscapeString(i.Value), i.setAt())
+				case "Apparmor Disabled", "Run As Non-Root Not Set", "Run As Root User", "Run As Root Group", "Root FSGroup", "Root Supplemental Group":
+					fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", i.Namespace, i.object(), i.container(), i.setAt())
 				}
 			}
 			fmt.Fprintln(rep, "</table></body></html>")
@@ -152,22 +194,62 @@ func ReportPSS(f []Finding, options *pflag.FlagSet, check string) {
 		if f != nil {
 			for _, i := range f {
 				switch i.Check {
-				case "hostpid", "hostnet", "hostipc", "privileged", "allowprivesc", "HostProcess", "Seccomp Disabled", "Unmasked Procmount", "Apparmor Disabled":
+				case "hostpid", "hostnet", "hostipc", "privileged", "allowprivesc", "HostProcess", "Unmasked Procmount":
 					if i.Container != "" {
-						fmt.Fprintf(rep, "namespace %s : pod %s : container %s\n", i.Namespace, i.Pod, i.Container)
+						fmt.Fprintf(rep, "namespace %s : pod %s : container %s\n", i.Namespace, i.object(), i.container())
 					} else {
-						fmt.Fprintf(rep, "namespace %s : pod %s\n", i.Namespace, i.Pod)
+						fmt.Fprintf(rep, "namespace %s : pod %s\n", i.Namespace, i.object())
 					}
 				case "Added Capabilities":
-					fmt.Fprintf(rep, "namespace %s : pod %s : container %s added capabilities %s \n", i.Namespace, i.Pod, i.Container, strings.Join(i.Capabilities[:], ","))
+					fmt.Fprintf(rep, "namespace %s : pod %s : container %s added capabilities %s \n", i.Namespace, i.object(), i.container(), strings.Join(i.Capabilities[:], ","))
 				case "Dropped Capabilities":
-					fmt.Fprintf(rep, "namespace %s : pod %s : container %s dropped capabilities %s \n", i.Namespace, i.Pod, i.Container, strings.Join(i.Capabilities[:], ","))
+					fmt.Fprintf(rep, "namespace %s : pod %s : container %s dropped capabilities %s \n", i.Namespace, i.object(), i.container(), strings.Join(i.Capabilities[:], ","))
 				case "Host Ports":
-					fmt.Fprintf(rep, "namespace %s : pod %s : container %s : port %d\n", i.Namespace, i.Pod, i.Container, i.Hostport)
+					fmt.Fprintf(rep, "namespace %s : pod %s : container %s : %s\n", i.Namespace, i.object(), i.container(), i.hostPort())
 				case "Host Path":
-					fmt.Fprintf(rep, "namespace %s : pod %s : volume %s : path %s\n", i.Namespace, i.Pod, i.Volume, i.Path)
+					if i.Container != "" {
+						fmt.Fprintf(rep, "namespace %s : pod %s : container %s : volume %s : %s\n", i.Namespace, i.object(), i.container(), i.Volume, i.hostPath())
+					} else {
+						fmt.Fprintf(rep, "namespace %s : pod %s : volume %s : %s\n", i.Namespace, i.object(), i.Volume, i.hostPath())
+					}
 				case "Unsafe Sysctl":
-					fmt.Fprintf(rep, "namespace %s : pod %s : unsafe sysctl %s", i.Namespace, i.Pod, i.Sysctl)
+					fmt.Fprintf(rep, "namespace %s : pod %s : unsafe sysctl %s\n", i.Namespace, i.object(), i.Sysctl)
+				case "Restricted Volume Types", "Risky Volumes":
+					fmt.Fprintf(rep, "namespace %s : pod %s : %s\n", i.Namespace, i.object(), i.volume())
+				case "Shared Process Namespace", "Host User Namespace", "Service Links":
+					fmt.Fprintf(rep, "namespace %s : pod %s\n", i.Namespace, i.object())
+				case "Host Aliases":
+					fmt.Fprintf(rep, "namespace %s : pod %s : hostAlias %s points to %s\n", i.Namespace, i.object(), i.Option, i.Value)
+				case "DNS Policy":
+					if i.Value != "" {
+						fmt.Fprintf(rep, "namespace %s : pod %s : %s : %s\n", i.Namespace, i.object(), i.Option, i.Value)
+					} else {
+						fmt.Fprintf(rep, "namespace %s : pod %s : %s\n", i.Namespace, i.object(), i.Option)
+					}
+				case "Windows Admin User", "GMSA Credential Spec":
+					fmt.Fprintf(rep, "namespace %s : pod %s : container %s : %s %s : %s\n", i.Namespace, i.object(), i.container(), i.Option, i.Value, i.setAt())
+				case "Windows Node Selector", "Windows Host Network":
+					fmt.Fprintf(rep, "namespace %s : pod %s : windows pod because %s\n", i.Namespace, i.object(), i.Reason)
+				case "Apparmor Localhost Profile", "Seccomp Localhost Profile":
+					fmt.Fprintf(rep, "namespace %s : pod %s : container %s : profile %s : %s\n", i.Namespace, i.object(), i.container(), i.Profile, i.setAt())
+				case "Capability Risk":
+					fmt.Fprintf(rep, "namespace %s : pod %s : container %s : %s\n", i.Namespace, i.object(), i.container(), i.capabilityRisk())
+				case "Seccomp Disabled":
+					if i.Profile != "" {
+						fmt.Fprintf(rep, "namespace %s : pod %s : container %s : profile %s : %s\n", i.Namespace, i.object(), i.container(), i.Profile, i.setAt())
+					} else {
+						fmt.Fprintf(rep, "namespace %s : pod %s : container %s : no profile set\n", i.Namespace, i.object(), i.container())
+					}
+				case "SELinux Options":
+					if i.Container != "" {
+						fmt.Fprintf(rep, "namespace %s : pod %s : container %s : %s %s : %s\n", i.Namespace, i.object(), i.container(), i.Option, i.Value, i.setAt())
+					} else {
+						fmt.Fprintf(rep, "namespace %s : pod %s : %s %s\n", i.Namespace, i.object(), i.Option, i.Value)
+					}
+				case "Apparmor Disabled", "Run As Non-Root Not Set", "Run As Root User", "Run As Root Group":
+					fmt.Fprintf(rep, "namespace %s : pod %s : container %s : %s\n", i.Namespace, i.object(), i.container(), i.setAt())
+				case "Root FSGroup", "Root Supplemental Group":
+					fmt.Fprintf(rep, "namespace %s : pod %s : %s\n", i.Namespace, i.object(), i.setAt())
 
 				}
 			}
@@ -178,6 +260,51 @@ func ReportPSS(f []Finding, options *pflag.FlagSet, check string) {
 	}
 }
 
+// CheckError is how a check that couldn't complete is reported in JSON output
+type CheckError struct {
+	Check string
+	Error string
+}
+
+// ReportError reports a check that couldn't complete, so it isn't mistaken for one with no findings
+func ReportError(checkErr error, options *pflag.FlagSet, check string) {
+	jsonrep, _ := options.GetBool("jsonrep")
+	htmlrep, _ := options.GetBool("htmlrep")
+	file, _ := options.GetString("file")
+	var rep *os.File
+	switch {
+	case jsonrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".json", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		js, err := json.MarshalIndent(CheckError{Check: check, Error: checkErr.Error()}, "", "  ")
+		if err != nil {
+			log.Print(err)
+		}
+		fmt.Fprintln(rep, string(js))
+	case htmlrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".html", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "<html><head>%s<title>Findings for the %s check</title></head><body>", style, check)
+		fmt.Fprintf(rep, "<h1>Findings for the %s check</h1>", check)
+		fmt.Fprintf(rep, "<p>Check could not complete: %s</p></body></html>\n", html.EscapeString(checkErr.Error()))
+	default:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "Findings for the %s check\n", check)
+		fmt.Fprintf(rep, "Check could not complete: %s\n", checkErr)
+		fmt.Fprintln(rep, "")
+	}
+}
+
 func ReportPrincipal(f []string, options *pflag.FlagSet, check string) {
 	jsonrep, _ := options.GetBool("jsonrep")
 	htmlrep, _ := options.GetBool("htmlrep")
@@ -283,7 +410,7 @@ func ReportImage(f []string, options *pflag.FlagSet, check string) {
 	}
 }
 
-func ReportRBAC(f v1.ClusterRoleBindingList, options *pflag.FlagSet, check string) {
+func ReportRBAC(f []Grant, options *pflag.FlagSet, check string) {
 	jsonrep, _ := options.GetBool("jsonrep")
 	htmlrep, _ := options.GetBool("htmlrep")
 	file, _ := options.GetString("file")
@@ -296,7 +423,7 @@ func ReportRBAC(f v1.ClusterRoleBindingList, options *pflag.FlagSet, check strin
 		} else {
 			rep = os.Stdout
 		}
-		if f.Items != nil {
+		if f != nil {
 			js, err := json.MarshalIndent(f, "", "  ")
 			if err != nil {
 				log.Print(err)
@@ -310,10 +437,10 @@ func ReportRBAC(f v1.ClusterRoleBindingList, options *pflag.FlagSet, check strin
 			rep = os.Stdout
 		}
 		fmt.Fprintf(rep, "<html><head>%s<title>RBAC Report</title></head><body>", style)
-		if f.Items != nil {
-			fmt.Fprintf(rep, "<table><tr><th>ClusterRoleBinding</th><th>Subjects</th><th>Role Ref</th></tr>")
-			for _, i := range f.Items {
-				fmt.Fprintf(rep, "<tr><td>%s</td>", i.Name)
+		if f != nil {
+			fmt.Fprintf(rep, "<table><tr><th>Binding</th><th>Scope</th><th>Subjects</th><th>Role Ref</th></tr>")
+			for _, i := range f {
+				fmt.Fprintf(rep, "<tr><td>%s %s</td><td>%s</td>", i.Kind, i.name(), i.Scope)
 				for _, s := range i.Subjects {
 					if s.Kind == "ServiceAccount" {
 						fmt.Fprintf(rep, "<td>Kind: %s, Name: %s, Namespace: %s</td>", s.Kind, s.Name, s.Namespace)
@@ -334,9 +461,10 @@ func ReportRBAC(f v1.ClusterRoleBindingList, options *pflag.FlagSet, check strin
 			rep = os.Stdout
 		}
 		fmt.Fprintf(rep, "Findings for the %s check\n", check)
-		if f.Items != nil {
-			for _, i := range f.Items {
-				fmt.Fprintf(rep, "ClusterRoleBinding %s\n", i.Name)
+		if f != nil {
+			for _, i := range f {
+				fmt.Fprintf(rep, "%s %s\n", i.Kind, i.name())
+				fmt.Fprintf(rep, "Scope: %s\n", i.Scope)
 				fmt.Fprintf(rep, "Subjects:\n")
 				for _, s := range i.Subjects {
 					if s.Kind == "ServiceAccount" {
@@ -352,3 +480,529 @@ func ReportRBAC(f v1.ClusterRoleBindingList, options *pflag.FlagSet, check strin
 		}
 	}
 }
+
+// object names the thing a finding is about. That's the pod name for running pods, the workload
+// and number of pods for findings grouped by workload, and Kind/name (template) for pod templates.
+// It's followed by the manifest it came from if there is one.
+func (f Finding) object() string {
+	name := f.Pod
+	switch {
+	case f.Replicas == 1:
+		name = f.Workload + " (1 pod)"
+	case f.Replicas > 1:
+		name = fmt.Sprintf("%s (%d pods)", f.Workload, f.Replicas)
+	case f.Kind != "" && f.Kind != "Pod":
+		name = f.Kind + "/" + f.Pod + " (template)"
+	case f.BarePod:
+		name += " (bare pod)"
+	}
+	if f.Ref != "" {
+		name += " (" + f.Ref + ")"
+	}
+	return name
+}
+
+// setAt describes where the value a finding is about was set
+func (f Finding) setAt() string {
+	switch f.SetAt {
+	case "":
+		return "not set"
+	case SetAtAnnotation:
+		return "set by annotation"
+	}
+	return "set at " + f.SetAt + " level"
+}
+
+// volume describes a volume by its name and type, with the reason it's risky if there is one
+func (f Finding) volume() string {
+	d := "volume " + f.Volume + " : type " + f.VolumeType
+	if f.Reason != "" {
+		d += " : " + f.Reason
+	}
+	return d
+}
+
+// hostPort describes a port opened on the node, e.g. port 10250/TCP on 0.0.0.0 (container port 8080, name https) : sensitive kubelet API
+func (f Finding) hostPort() string {
+	d := fmt.Sprintf("port %d/%s", f.Hostport, f.Protocol)
+	if f.HostIP != "" {
+		d += " on " + f.HostIP
+	}
+	if f.PortName != "" {
+		d += fmt.Sprintf(" (container port %d, name %s)", f.ContainerPort, f.PortName)
+	} else {
+		d += fmt.Sprintf(" (container port %d)", f.ContainerPort)
+	}
+	if f.HostNetwork {
+		d += " : host network"
+	}
+	if f.SensitivePort != "" {
+		d += " : sensitive " + f.SensitivePort
+	}
+	return d
+}
+
+// capabilityRisk describes a container's effective capabilities and what's risky about them
+func (f Finding) capabilityRisk() string {
+	d := "effective capabilities " + strings.Join(f.Capabilities, ",")
+	if f.Capabilities == nil {
+		d = "no effective capabilities"
+	}
+	if f.Dangerous != nil {
+		d += " : dangerous " + strings.Join(f.Dangerous, ",")
+	}
+	if f.NoDropAll {
+		d += " : doesn't drop ALL"
+	}
+	return d
+}
+
+// hostPath describes a host path mount, e.g. path /etc (Directory) : read only : risk high
+func (f Finding) hostPath() string {
+	d := "path " + f.Path
+	if f.PathType != "" {
+		d += " (" + f.PathType + ")"
+	}
+	if f.SubPath != "" {
+		d += " : subPath " + f.SubPath
+	}
+	d += " : " + f.access()
+	if f.Propagation != "" {
+		d += " : propagation " + f.Propagation
+	}
+	return d + " : risk " + f.Risk
+}
+
+// access is how a container mounts a host path volume
+func (f Finding) access() string {
+	switch {
+	case f.Container == "":
+		return "not mounted"
+	case f.ReadOnly:
+		return "read only"
+	}
+	return "writable"
+}
+
+// container is the finding's container name, followed by its type if it isn't a regular container
+func (f Finding) container() string {
+	if f.ContainerType == "" || f.ContainerType == ContainerRegular {
+		return f.Container
+	}
+	return f.Container + " (" + f.ContainerType + ")"
+}
+
+// name is the name of a binding, followed by the manifest it came from if there is one
+func (g Grant) name() string {
+	if g.Ref != "" {
+		return g.Name + " (" + g.Ref + ")"
+	}
+	return g.Name
+}
+
+// ReportLevels reports the Pod Security Standards level of each namespace, followed by
+// the controls violated by each pod that doesn't meet the restricted level
+func ReportLevels(r *LevelReport, options *pflag.FlagSet, check string) {
+	jsonrep, _ := options.GetBool("jsonrep")
+	htmlrep, _ := options.GetBool("htmlrep")
+	file, _ := options.GetString("file")
+	var rep *os.File
+	switch {
+	case jsonrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".json", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		js, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			log.Print(err)
+		}
+		fmt.Fprintln(rep, string(js))
+	case htmlrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".html", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "<html><head>%s<title>%s</title></head><body>", style, check)
+		fmt.Fprintf(rep, "<h1>%s</h1>", check)
+		if r.Namespaces == nil {
+			fmt.Fprintln(rep, "<p>No pods found</p></body></html>")
+			return
+		}
+		fmt.Fprintln(rep, "<table><tr><th>Namespace</th><th>Version</th><th>Level</th><th>Pods</th><th>Pods failing baseline</th><th>Pods failing restricted</th><th>Templates</th><th>Templates failing baseline</th><th>Templates failing restricted</th></tr>")
+		for _, ns := range r.Namespaces {
+			fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td></tr>", ns.Namespace, ns.Version, ns.Level, ns.Pods, ns.FailBaseline, ns.FailRestricted, ns.Templates, ns.TemplatesFailBaseline, ns.TemplatesFailRestricted)
+		}
+		fmt.Fprintln(rep, "</table>")
+		fmt.Fprintln(rep, "<h2>Violations</h2><table><tr><th>Namespace</th><th>Pod</th><th>Level</th><th>Control</th><th>Container</th><th>Detail</th></tr>")
+		for _, p := range r.violating() {
+			for _, v := range p.Violations {
+				fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s (%s)</td><td>%s</td><td>%s</td></tr>", p.Namespace, p.object(), p.Level, v.Control, v.Level, v.Container, html.EscapeString(v.Detail))
+			}
+		}
+		fmt.Fprintln(rep, "</table></body></html>")
+	default:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "Findings for the %s check\n", check)
+		if r.Namespaces == nil {
+			fmt.Fprintln(rep, "No pods found!")
+			fmt.Fprintln(rep, "")
+			return
+		}
+		for _, ns := range r.Namespaces {
+			fmt.Fprintln(rep, ns.summary())
+		}
+		for _, p := range r.violating() {
+			fmt.Fprintf(rep, "namespace %s : pod %s : %s\n", p.Namespace, p.object(), p.Level)
+			for _, v := range p.Violations {
+				if v.Container != "" {
+					fmt.Fprintf(rep, "  %s %s : container %s : %s\n", v.Level, v.Control, v.Container, v.Detail)
+				} else {
+					fmt.Fprintf(rep, "  %s %s : %s\n", v.Level, v.Control, v.Detail)
+				}
+			}
+		}
+		fmt.Fprintln(rep, "")
+	}
+}
+
+// violating returns the pods that fail at least one control
+func (r *LevelReport) violating() []PodLevel {
+	var pods []PodLevel
+	for _, p := range r.Pods {
+		if p.Violations != nil {
+			pods = append(pods, p)
+		}
+	}
+	return pods
+}
+
+func (ns NamespaceLevel) summary() string {
+	summary := fmt.Sprintf("namespace %s : %s : %s : %d pods, %d fail baseline, %d fail restricted", ns.Namespace, ns.Version, ns.Level, ns.Pods, ns.FailBaseline, ns.FailRestricted)
+	if ns.Templates > 0 {
+		summary += fmt.Sprintf(" : %d templates, %d fail baseline, %d fail restricted", ns.Templates, ns.TemplatesFailBaseline, ns.TemplatesFailRestricted)
+	}
+	return summary
+}
+
+// object names the pod a level is for, in the same way as Finding.object
+func (p PodLevel) object() string {
+	return Finding{Pod: p.Pod, Kind: p.Kind, Ref: p.Ref}.object()
+}
+
+// ReportPSA reports the Pod Security Admission migration table, one row per namespace
+func ReportPSA(namespaces []NamespacePSA, options *pflag.FlagSet, check string) {
+	jsonrep, _ := options.GetBool("jsonrep")
+	htmlrep, _ := options.GetBool("htmlrep")
+	file, _ := options.GetString("file")
+	var rep *os.File
+	switch {
+	case jsonrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".json", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		if namespaces != nil {
+			js, err := json.MarshalIndent(namespaces, "", "  ")
+			if err != nil {
+				log.Print(err)
+			}
+			fmt.Fprintln(rep, string(js))
+		}
+	case htmlrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".html", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "<html><head>%s<title>%s</title></head><body>", style, check)
+		fmt.Fprintf(rep, "<h1>%s</h1>", check)
+		if namespaces == nil {
+			fmt.Fprintln(rep, "<p>No namespaces found</p></body></html>")
+			return
+		}
+		fmt.Fprintln(rep, "<table><tr><th>Namespace</th><th>Enforce</th><th>Audit</th><th>Warn</th><th>Suggested</th><th>Status</th><th>Pods that would break at baseline</th><th>Pods that would break at restricted</th></tr>")
+		for _, ns := range namespaces {
+			fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", ns.Namespace, psaLabel(ns.Enforce, ns.EnforceVersion), psaLabel(ns.Audit, ns.AuditVersion), psaLabel(ns.Warn, ns.WarnVersion), ns.Suggested, ns.Status(), strings.Join(ns.BreakBaseline, "<br/>"), strings.Join(ns.BreakRestricted, "<br/>"))
+		}
+		fmt.Fprintln(rep, "</table></body></html>")
+	default:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "Findings for the %s check\n", check)
+		if namespaces == nil {
+			fmt.Fprintln(rep, "No namespaces found!")
+		}
+		for _, ns := range namespaces {
+			fmt.Fprintln(rep, ns.summary())
+			if ns.ViolatingEnforce != nil {
+				fmt.Fprintf(rep, "  violating enforce level: %s\n", strings.Join(ns.ViolatingEnforce, ", "))
+			}
+			if ns.BreakBaseline != nil && levelRank(ns.Enforce) < levelRank(LevelBaseline) {
+				fmt.Fprintf(rep, "  would break at baseline: %s\n", strings.Join(ns.BreakBaseline, ", "))
+			}
+			if ns.BreakRestricted != nil && levelRank(ns.Enforce) < levelRank(LevelRestricted) {
+				fmt.Fprintf(rep, "  would break at restricted: %s\n", strings.Join(ns.BreakRestricted, ", "))
+			}
+		}
+		fmt.Fprintln(rep, "")
+	}
+}
+
+// ReportSeccompCoverage reports the seccomp coverage of each namespace, followed by the total for all of them
+func ReportSeccompCoverage(coverage []SeccompCoverage, options *pflag.FlagSet, check string) {
+	jsonrep, _ := options.GetBool("jsonrep")
+	htmlrep, _ := options.GetBool("htmlrep")
+	file, _ := options.GetString("file")
+	var rep *os.File
+	switch {
+	case jsonrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".json", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		if coverage != nil {
+			js, err := json.MarshalIndent(coverage, "", "  ")
+			if err != nil {
+				log.Print(err)
+			}
+			fmt.Fprintln(rep, string(js))
+		}
+	case htmlrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".html", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "<html><head>%s<title>%s</title></head><body>", style, check)
+		fmt.Fprintf(rep, "<h1>%s</h1>", check)
+		if coverage == nil {
+			fmt.Fprintln(rep, "<p>No containers found</p></body></html>")
+			return
+		}
+		fmt.Fprintln(rep, "<table><tr><th>Namespace</th><th>Containers</th><th>RuntimeDefault</th><th>Localhost</th><th>Unconfined</th><th>Not set</th></tr>")
+		for _, ns := range append(coverage, totalCoverage(coverage)) {
+			name := ns.Namespace
+			if name == "" {
+				name = "All namespaces"
+			}
+			fmt.Fprintf(rep, "<tr><td>%s</td><td>%d</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>", name, ns.Containers, ns.percent(ns.RuntimeDefault), ns.percent(ns.Localhost), ns.percent(ns.Unconfined), ns.percent(ns.Unset))
+		}
+		fmt.Fprintln(rep, "</table></body></html>")
+	default:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "Findings for the %s check\n", check)
+		if coverage == nil {
+			fmt.Fprintln(rep, "No containers found!")
+		} else {
+			for _, ns := range coverage {
+				fmt.Fprintln(rep, ns.summary())
+			}
+			fmt.Fprintln(rep, totalCoverage(coverage).summary())
+		}
+		fmt.Fprintln(rep, "")
+	}
+}
+
+// totalCoverage adds up the coverage of every namespace
+func totalCoverage(coverage []SeccompCoverage) SeccompCoverage {
+	var total SeccompCoverage
+	for _, ns := range coverage {
+		total.Containers += ns.Containers
+		total.RuntimeDefault += ns.RuntimeDefault
+		total.Localhost += ns.Localhost
+		total.Unconfined += ns.Unconfined
+		total.Unset += ns.Unset
+	}
+	return total
+}
+
+func (c SeccompCoverage) summary() string {
+	return fmt.Sprintf("%s : %d containers : RuntimeDefault %s : Localhost %s : Unconfined %s : not set %s", c.name(), c.Containers, c.percent(c.RuntimeDefault), c.percent(c.Localhost), c.percent(c.Unconfined), c.percent(c.Unset))
+}
+
+// name is the namespace the coverage is for, the total for all namespaces has no namespace
+func (c SeccompCoverage) name() string {
+	if c.Namespace == "" {
+		return "all namespaces"
+	}
+	return "namespace " + c.Namespace
+}
+
+// percent shows a count along with its share of the namespace's containers, e.g. 3 (60%)
+func (c SeccompCoverage) percent(n int) string {
+	if c.Containers == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d (%d%%)", n, n*100/c.Containers)
+}
+
+func (ns NamespacePSA) summary() string {
+	return fmt.Sprintf("namespace %s : enforce %s : audit %s : warn %s : suggested enforce %s : %s", ns.Namespace, psaLabel(ns.Enforce, ns.EnforceVersion), psaLabel(ns.Audit, ns.AuditVersion), psaLabel(ns.Warn, ns.WarnVersion), ns.Suggested, ns.Status())
+}
+
+// psaLabel shows a Pod Security Admission level with its version, e.g. baseline:v1.28
+func psaLabel(level, version string) string {
+	if level == "" {
+		return "none"
+	}
+	if version == "" {
+		return level
+	}
+	return level + ":" + version
+}
+
+// ReportMultiCluster reports a scan of several clusters. It starts with a summary for each
+// cluster, then the results of each check across every cluster, then the findings that
+// turned up in more than one cluster.
+func ReportMultiCluster(r MultiClusterReport, options *pflag.FlagSet) {
+	jsonrep, _ := options.GetBool("jsonrep")
+	htmlrep, _ := options.GetBool("htmlrep")
+	file, _ := options.GetString("file")
+	var rep *os.File
+	switch {
+	case jsonrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".json", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		js, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			log.Print(err)
+		}
+		fmt.Fprintln(rep, string(js))
+	case htmlrep:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".html", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintf(rep, "<html><head>%s<title>Multi-cluster Report</title></head><body>", style)
+		fmt.Fprintln(rep, "<h1>Cluster Summary</h1><table><tr><th>Context</th><th>Cluster</th><th>Findings</th><th>Checks that could not complete</th></tr>")
+		for _, c := range r.Clusters {
+			fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%d</td><td>%d</td></tr>", html.EscapeString(c.Context), html.EscapeString(c.Cluster), c.Total, len(c.Errors))
+		}
+		fmt.Fprintln(rep, "</table>")
+		fmt.Fprintln(rep, "<h1>Findings in more than one cluster</h1>")
+		if r.Common != nil {
+			fmt.Fprintln(rep, "<table><tr><th>Check</th><th>Namespace</th><th>Object</th><th>Container</th><th>Detail</th><th>Clusters</th></tr>")
+			for _, c := range r.Common {
+				fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%d/%d: %s</td></tr>", c.Check, c.Namespace, c.Object, c.Container, c.Detail, len(c.Contexts), len(r.Clusters), html.EscapeString(strings.Join(c.Contexts, ", ")))
+			}
+			fmt.Fprintln(rep, "</table>")
+		} else {
+			fmt.Fprintln(rep, "<p>No findings</p>")
+		}
+		fmt.Fprintln(rep, "<h1>Findings</h1><table><tr><th>Check</th><th>Context</th><th>Finding</th></tr>")
+		for _, res := range r.Results {
+			for _, line := range res.lines() {
+				fmt.Fprintf(rep, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>", res.Check, html.EscapeString(res.Context), html.EscapeString(line))
+			}
+		}
+		fmt.Fprintln(rep, "</table></body></html>")
+	default:
+		if file != "" {
+			rep, _ = os.OpenFile(file+".txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		} else {
+			rep = os.Stdout
+		}
+		fmt.Fprintln(rep, "Cluster summary")
+		for _, c := range r.Clusters {
+			fmt.Fprintf(rep, "context %s : cluster %s : %d findings", c.Context, c.Cluster, c.Total)
+			if len(c.Errors) > 0 {
+				fmt.Fprintf(rep, " : %d check(s) could not complete", len(c.Errors))
+			}
+			fmt.Fprintln(rep, "")
+		}
+		fmt.Fprintln(rep, "")
+		fmt.Fprintln(rep, "Findings in more than one cluster")
+		if r.Common != nil {
+			for _, c := range r.Common {
+				fmt.Fprintf(rep, "%s : ", c.Check)
+				if c.Namespace != "" {
+					fmt.Fprintf(rep, "namespace %s : ", c.Namespace)
+				}
+				fmt.Fprint(rep, c.Object)
+				if c.Container != "" {
+					fmt.Fprintf(rep, " : container %s", c.Container)
+				}
+				if c.Detail != "" {
+					fmt.Fprintf(rep, " : %s", c.Detail)
+				}
+				fmt.Fprintf(rep, " : %d/%d clusters (%s)\n", len(c.Contexts), len(r.Clusters), strings.Join(c.Contexts, ", "))
+			}
+		} else {
+			fmt.Fprintln(rep, "No findings!")
+		}
+		fmt.Fprintln(rep, "")
+		// Results are grouped by check, one entry per cluster
+		for start := 0; start < len(r.Results); start += len(r.Clusters) {
+			fmt.Fprintf(rep, "Findings for the %s check\n", r.Results[start].Check)
+			found := false
+			for _, res := range r.Results[start : start+len(r.Clusters)] {
+				for _, line := range res.lines() {
+					fmt.Fprintf(rep, "[%s] %s\n", res.Context, line)
+					found = true
+				}
+			}
+			if !found {
+				fmt.Fprintln(rep, "No findings!")
+			}
+			fmt.Fprintln(rep, "")
+		}
+	}
+}
+
+// lines gives a one line description of each result, used when several clusters are listed together
+func (r ClusterCheckResult) lines() []string {
+	var lines []string
+	if r.Error != "" {
+		lines = append(lines, "Check could not complete: "+r.Error)
+	}
+	for _, f := range r.Findings {
+		line := fmt.Sprintf("namespace %s : pod %s", f.Namespace, f.object())
+		if f.Container != "" {
+			line += " : container " + f.Container
+		}
+		if d := f.detail(); d != "" {
+			line += " : " + d
+		}
+		lines = append(lines, line)
+	}
+	for _, b := range r.Bindings {
+		var subjects []string
+		for _, s := range b.Subjects {
+			subjects = append(subjects, s.Kind+" "+s.Name)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s : %s : %s %s : subjects %s", b.Kind, b.name(), b.Scope, b.RoleRef.Kind, b.RoleRef.Name, strings.Join(subjects, ", ")))
+	}
+	lines = append(lines, r.Items...)
+	if r.Levels != nil {
+		for _, ns := range r.Levels.Namespaces {
+			lines = append(lines, ns.summary())
+		}
+	}
+	for _, ns := range r.PSA {
+		lines = append(lines, ns.summary())
+	}
+	for _, ns := range r.Coverage {
+		lines = append(lines, ns.summary())
+	}
+	return lines
+}
@@ -0,0 +1,249 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// scope decides which namespaces and pods the checks look at. It's built from the
+// namespace and selector flags the first time it's needed.
+type scope struct {
+	// only is the namespace set with --namespace
+	only    string
+	include []namespaceMatcher
+	exclude []namespaceMatcher
+	// namespaceSelector is matched against namespaceLabels, which holds the labels of every namespace in the snapshot
+	namespaceSelector labels.Selector
+	namespaceLabels   map[string]labels.Set
+	podSelector       labels.Selector
+	fieldSelector     fields.Selector
+	// templateFieldSelector is fieldSelector without its status.* terms, as pod templates have no status
+	templateFieldSelector fields.Selector
+	// offline is set when the pod selectors haven't already been applied by the API server
+	offline bool
+}
+
+// namespaceMatcher reports whether a namespace matches one of the --include-namespaces or --exclude-namespaces patterns
+type namespaceMatcher func(namespace string) bool
+
+// newNamespaceMatcher creates a matcher for a pattern. Patterns wrapped in slashes like /^team-/ are
+// regular expressions, patterns containing *, ? or [ are globs and anything else has to match exactly.
+func newNamespaceMatcher(pattern string) (namespaceMatcher, error) {
+	switch {
+	case len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/"):
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern %q: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	case strings.ContainsAny(pattern, "*?["):
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern %q: %w", pattern, err)
+		}
+		return func(namespace string) bool {
+			ok, _ := path.Match(pattern, namespace)
+			return ok
+		}, nil
+	}
+	return func(namespace string) bool { return namespace == pattern }, nil
+}
+
+func newNamespaceMatchers(patterns []string) ([]namespaceMatcher, error) {
+	var matchers []namespaceMatcher
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		m, err := newNamespaceMatcher(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+func matchAny(matchers []namespaceMatcher, namespace string) bool {
+	for _, m := range matchers {
+		if m(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOptions checks the flags that are only parsed when a check first needs them, so a mistake in
+// one of them is reported as a usage error before anything runs rather than as every check failing
+func ValidateOptions(options *pflag.FlagSet) error {
+	if _, err := newScope(options); err != nil {
+		return err
+	}
+	if v, _ := options.GetString("pss-version"); v != "" {
+		if _, err := rulesFor(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newScope builds a scope from the namespace and selector flags. The labels of the namespaces
+// --namespace-selector is matched against come from the snapshot, so they're added by Snapshot.scope.
+func newScope(options *pflag.FlagSet) (*scope, error) {
+	only, _ := options.GetString("namespace")
+	sc := &scope{only: only}
+	var err error
+	includes, _ := options.GetStringSlice("include-namespaces")
+	if sc.include, err = newNamespaceMatchers(includes); err != nil {
+		return nil, err
+	}
+	excludes, _ := options.GetStringSlice("exclude-namespaces")
+	// --exclude is the original name for --exclude-namespaces
+	if exclude, _ := options.GetString("exclude"); exclude != "" {
+		excludes = append(excludes, strings.Split(exclude, ",")...)
+	}
+	if sc.exclude, err = newNamespaceMatchers(excludes); err != nil {
+		return nil, err
+	}
+	if podSelector, _ := options.GetString("pod-selector"); podSelector != "" {
+		if sc.podSelector, err = labels.Parse(podSelector); err != nil {
+			return nil, fmt.Errorf("invalid --pod-selector: %w", err)
+		}
+	}
+	if fieldSelector, _ := options.GetString("field-selector"); fieldSelector != "" {
+		if sc.fieldSelector, err = fields.ParseSelector(fieldSelector); err != nil {
+			return nil, fmt.Errorf("invalid --field-selector: %w", err)
+		}
+		sc.templateFieldSelector = withoutStatus(sc.fieldSelector)
+	}
+	if namespaceSelector, _ := options.GetString("namespace-selector"); namespaceSelector != "" {
+		if sc.namespaceSelector, err = labels.Parse(namespaceSelector); err != nil {
+			return nil, fmt.Errorf("invalid --namespace-selector: %w", err)
+		}
+	}
+	return sc, nil
+}
+
+// scope returns the snapshot's scope, building it from the flags on first use
+func (s *Snapshot) scope() (*scope, error) {
+	if s.scopeCache != nil {
+		return s.scopeCache, nil
+	}
+	sc, err := newScope(s.options)
+	if err != nil {
+		return nil, err
+	}
+	if sc.namespaceSelector != nil {
+		namespaces, err := s.Namespaces()
+		if err != nil {
+			return nil, err
+		}
+		sc.namespaceLabels = make(map[string]labels.Set)
+		for _, ns := range namespaces {
+			sc.namespaceLabels[ns.Name] = ns.Labels
+		}
+	}
+	sc.offline = s.offline
+	s.scopeCache = sc
+	return sc, nil
+}
+
+// includesNamespace reports whether the checks should look at a namespace
+func (sc *scope) includesNamespace(namespace string) bool {
+	if sc.only != "" && namespace != sc.only {
+		return false
+	}
+	if len(sc.include) > 0 && !matchAny(sc.include, namespace) {
+		return false
+	}
+	if matchAny(sc.exclude, namespace) {
+		return false
+	}
+	if sc.namespaceSelector != nil {
+		nsLabels, ok := sc.namespaceLabels[namespace]
+		if !ok {
+			// Manifests don't always include their namespaces. The API server labels every
+			// namespace with its name, so that label can still be matched.
+			nsLabels = labels.Set{corev1.LabelMetadataName: namespace}
+		}
+		if !sc.namespaceSelector.Matches(nsLabels) {
+			return false
+		}
+	}
+	return true
+}
+
+// includesPod reports whether the checks should look at a pod. For live clusters the pod and
+// field selectors have already been applied by the API server, so only the namespace is checked.
+func (sc *scope) includesPod(meta metav1.ObjectMeta, spec corev1.PodSpec, status corev1.PodStatus) bool {
+	if !sc.includesNamespace(meta.Namespace) {
+		return false
+	}
+	if !sc.offline {
+		return true
+	}
+	return sc.matchesSelectors(sc.fieldSelector, meta, spec, status)
+}
+
+// includesTemplate reports whether the checks should look at a controller's pod template.
+// The API server never sees templates, so the pod and field selectors are always applied here.
+// Templates have no status, so status.* terms are left out rather than ruling out every template.
+func (sc *scope) includesTemplate(meta metav1.ObjectMeta, spec corev1.PodSpec) bool {
+	return sc.includesNamespace(meta.Namespace) && sc.matchesSelectors(sc.templateFieldSelector, meta, spec, corev1.PodStatus{})
+}
+
+func (sc *scope) matchesSelectors(fieldSelector fields.Selector, meta metav1.ObjectMeta, spec corev1.PodSpec, status corev1.PodStatus) bool {
+	if sc.podSelector != nil && !sc.podSelector.Matches(labels.Set(meta.Labels)) {
+		return false
+	}
+	if fieldSelector != nil && !fieldSelector.Matches(podFields(meta, spec, status)) {
+		return false
+	}
+	return true
+}
+
+// withoutStatus returns a field selector with its status.* terms removed
+func withoutStatus(selector fields.Selector) fields.Selector {
+	var terms []fields.Selector
+	for _, r := range selector.Requirements() {
+		if strings.HasPrefix(r.Field, "status.") {
+			continue
+		}
+		if r.Operator == selection.NotEquals {
+			terms = append(terms, fields.OneTermNotEqualSelector(r.Field, r.Value))
+		} else {
+			terms = append(terms, fields.OneTermEqualSelector(r.Field, r.Value))
+		}
+	}
+	return fields.AndSelectors(terms...)
+}
+
+// podFields are the pod fields a field selector can match on, the same set the API server supports
+func podFields(meta metav1.ObjectMeta, spec corev1.PodSpec, status corev1.PodStatus) fields.Set {
+	return fields.Set{
+		"metadata.name":            meta.Name,
+		"metadata.namespace":       meta.Namespace,
+		"spec.nodeName":            spec.NodeName,
+		"spec.restartPolicy":       string(spec.RestartPolicy),
+		"spec.schedulerName":       spec.SchedulerName,
+		"spec.serviceAccountName":  spec.ServiceAccountName,
+		"spec.hostNetwork":         strconv.FormatBool(spec.HostNetwork),
+		"status.phase":             string(status.Phase),
+		"status.podIP":             status.PodIP,
+		"status.nominatedNodeName": status.NominatedNodeName,
+	}
+}
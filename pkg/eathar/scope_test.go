@@ -0,0 +1,45 @@
+package eathar
+
+import "testing"
+
+func TestNewNamespaceMatcher(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		namespace string
+		want      bool
+	}{
+		{"exact match", "kube-system", "kube-system", true},
+		{"exact doesn't match prefix", "kube", "kubeflow", false},
+		{"exact doesn't match substring", "kube", "my-kube-app", false},
+		{"exact doesn't match longer name", "kube-system", "kube-system-2", false},
+		{"glob star", "kube-*", "kube-public", true},
+		{"glob star needs prefix", "kube-*", "kubeflow", false},
+		{"glob question mark", "team-?", "team-a", true},
+		{"glob question mark is one character", "team-?", "team-ab", false},
+		{"glob class", "team-[ab]", "team-b", true},
+		{"glob class outside set", "team-[ab]", "team-c", false},
+		{"regex anchored", "/^team-(a|b)$/", "team-a", true},
+		{"regex anchored no match", "/^team-(a|b)$/", "team-ab", false},
+		{"regex unanchored matches substring", "/kube/", "my-kube-app", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newNamespaceMatcher(tt.pattern)
+			if err != nil {
+				t.Fatalf("newNamespaceMatcher(%q) returned error: %v", tt.pattern, err)
+			}
+			if got := m(tt.namespace); got != tt.want {
+				t.Errorf("pattern %q against %q = %v, want %v", tt.pattern, tt.namespace, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewNamespaceMatcherInvalid(t *testing.T) {
+	for _, pattern := range []string{"/pro[/", "team-["} {
+		if _, err := newNamespaceMatcher(pattern); err == nil {
+			t.Errorf("newNamespaceMatcher(%q) returned no error", pattern)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The kinds of container in a pod. Sidecars are init containers with restartPolicy: Always,
+// which keep running alongside the regular containers.
+const (
+	ContainerRegular   = "regular"
+	ContainerInit      = "init"
+	ContainerSidecar   = "sidecar"
+	ContainerEphemeral = "ephemeral"
+)
+
+// Where the effective value of a security context setting came from. The container's securityContext
+// overrides the pod's. Settings that aren't set anywhere have an empty SetAt.
+const (
+	SetAtPod        = "pod"
+	SetAtContainer  = "container"
+	SetAtAnnotation = "annotation"
+)
+
+// The deprecated seccomp annotations, one for the whole pod and one per container with the container name after the prefix
+const (
+	seccompPodAnnotation             = "seccomp.security.alpha.kubernetes.io/pod"
+	seccompContainerAnnotationPrefix = "container.seccomp.security.alpha.kubernetes.io/"
+)
+
+// apparmorAnnotationPrefix is the prefix of the deprecated per-container AppArmor annotations, the container name follows it
+const apparmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// Setting is the effective value of one security context setting and where it was set.
+// Value is nil if it isn't set at the pod or the container level.
+type Setting[T any] struct {
+	Value *T
+	SetAt string
+}
+
+// IsSet reports whether the setting was set anywhere
+func (s Setting[T]) IsSet() bool {
+	return s.Value != nil
+}
+
+// EffectiveContainer is a container with its security context resolved against the pod's.
+// Settings that can only be set on the container are included too, so checks only need to look here.
+type EffectiveContainer struct {
+	Name string
+	Type string
+	// Container is the container's spec. Ephemeral containers are converted to a Container.
+	Container corev1.Container
+
+	RunAsUser       Setting[int64]
+	RunAsGroup      Setting[int64]
+	RunAsNonRoot    Setting[bool]
+	SeccompProfile  Setting[corev1.SeccompProfile]
+	SELinuxOptions  Setting[corev1.SELinuxOptions]
+	HostProcess     Setting[bool]
+	AppArmorProfile Setting[corev1.AppArmorProfile]
+	// The Windows options, each can be set on the pod or the container
+	RunAsUserName          Setting[string]
+	GMSACredentialSpecName Setting[string]
+	GMSACredentialSpec     Setting[string]
+
+	Privileged               Setting[bool]
+	AllowPrivilegeEscalation Setting[bool]
+	Capabilities             Setting[corev1.Capabilities]
+	ProcMount                Setting[corev1.ProcMountType]
+	ReadOnlyRootFilesystem   Setting[bool]
+}
+
+// EffectiveContainers returns every container in a pod (init, sidecar, regular and ephemeral)
+// with its effective security context
+func EffectiveContainers(pod PodTarget) []EffectiveContainer {
+	var containers []EffectiveContainer
+	for _, c := range pod.Spec.InitContainers {
+		containerType := ContainerInit
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			containerType = ContainerSidecar
+		}
+		containers = append(containers, resolveContainer(pod, c, containerType))
+	}
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, resolveContainer(pod, c, ContainerRegular))
+	}
+	for _, ec := range pod.Spec.EphemeralContainers {
+		containers = append(containers, resolveContainer(pod, corev1.Container(ec.EphemeralContainerCommon), ContainerEphemeral))
+	}
+	return containers
+}
+
+func resolveContainer(pod PodTarget, c corev1.Container, containerType string) EffectiveContainer {
+	psc := pod.Spec.SecurityContext
+	if psc == nil {
+		psc = &corev1.PodSecurityContext{}
+	}
+	csc := c.SecurityContext
+	if csc == nil {
+		csc = &corev1.SecurityContext{}
+	}
+	ec := EffectiveContainer{
+		Name:                     c.Name,
+		Type:                     containerType,
+		Container:                c,
+		RunAsUser:                inherit(psc.RunAsUser, csc.RunAsUser),
+		RunAsGroup:               inherit(psc.RunAsGroup, csc.RunAsGroup),
+		RunAsNonRoot:             inherit(psc.RunAsNonRoot, csc.RunAsNonRoot),
+		SeccompProfile:           inherit(psc.SeccompProfile, csc.SeccompProfile),
+		SELinuxOptions:           inherit(psc.SELinuxOptions, csc.SELinuxOptions),
+		Privileged:               inherit(nil, csc.Privileged),
+		AllowPrivilegeEscalation: inherit(nil, csc.AllowPrivilegeEscalation),
+		Capabilities:             inherit(nil, csc.Capabilities),
+		ProcMount:                inherit(nil, csc.ProcMount),
+		ReadOnlyRootFilesystem:   inherit(nil, csc.ReadOnlyRootFilesystem),
+	}
+	pwo := psc.WindowsOptions
+	if pwo == nil {
+		pwo = &corev1.WindowsSecurityContextOptions{}
+	}
+	cwo := csc.WindowsOptions
+	if cwo == nil {
+		cwo = &corev1.WindowsSecurityContextOptions{}
+	}
+	ec.HostProcess = inherit(pwo.HostProcess, cwo.HostProcess)
+	ec.RunAsUserName = inherit(pwo.RunAsUserName, cwo.RunAsUserName)
+	ec.GMSACredentialSpecName = inherit(pwo.GMSACredentialSpecName, cwo.GMSACredentialSpecName)
+	ec.GMSACredentialSpec = inherit(pwo.GMSACredentialSpec, cwo.GMSACredentialSpec)
+	// Fields win over annotations at the same level, and anything at the container level wins over the pod
+	if ec.SeccompProfile.SetAt != SetAtContainer {
+		if annotation, ok := pod.Annotations[seccompContainerAnnotationPrefix+c.Name]; ok {
+			profile := seccompFromAnnotation(annotation)
+			ec.SeccompProfile = Setting[corev1.SeccompProfile]{Value: &profile, SetAt: SetAtAnnotation}
+		} else if annotation, ok := pod.Annotations[seccompPodAnnotation]; ok && !ec.SeccompProfile.IsSet() {
+			profile := seccompFromAnnotation(annotation)
+			ec.SeccompProfile = Setting[corev1.SeccompProfile]{Value: &profile, SetAt: SetAtAnnotation}
+		}
+	}
+	ec.AppArmorProfile = inherit(psc.AppArmorProfile, csc.AppArmorProfile)
+	// The kubelet uses the container's field first, then the annotation, then the pod's field
+	if annotation, ok := pod.Annotations[apparmorAnnotationPrefix+c.Name]; ok && ec.AppArmorProfile.SetAt != SetAtContainer {
+		profile := apparmorFromAnnotation(annotation)
+		ec.AppArmorProfile = Setting[corev1.AppArmorProfile]{Value: &profile, SetAt: SetAtAnnotation}
+	}
+	return ec
+}
+
+// seccompFromAnnotation converts a seccomp annotation value (runtime/default, docker/default, localhost/<path> or unconfined)
+// to the profile the seccompProfile field would hold
+func seccompFromAnnotation(value string) corev1.SeccompProfile {
+	switch {
+	case value == "runtime/default", value == "docker/default":
+		return corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	case value == "unconfined":
+		return corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}
+	case strings.HasPrefix(value, "localhost/"):
+		profile := strings.TrimPrefix(value, "localhost/")
+		return corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: &profile}
+	}
+	return corev1.SeccompProfile{Type: corev1.SeccompProfileType(value)}
+}
+
+// apparmorFromAnnotation converts an AppArmor annotation value (runtime/default, localhost/<profile> or unconfined)
+// to the profile the appArmorProfile field would hold. Values the kubelet doesn't know are kept as the type so they get reported.
+func apparmorFromAnnotation(value string) corev1.AppArmorProfile {
+	switch {
+	case value == "runtime/default":
+		return corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeRuntimeDefault}
+	case value == "unconfined":
+		return corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeUnconfined}
+	case strings.HasPrefix(value, "localhost/"):
+		profile := strings.TrimPrefix(value, "localhost/")
+		return corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeLocalhost, LocalhostProfile: &profile}
+	}
+	return corev1.AppArmorProfile{Type: corev1.AppArmorProfileType(value)}
+}
+
+// inherit resolves a setting that can be set on the pod and the container, the container's value wins
+func inherit[T any](pod, container *T) Setting[T] {
+	if container != nil {
+		return Setting[T]{Value: container, SetAt: SetAtContainer}
+	}
+	if pod != nil {
+		return Setting[T]{Value: pod, SetAt: SetAtPod}
+	}
+	return Setting[T]{}
+}
@@ -0,0 +1,139 @@
+package eathar
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveContainerRunAsUser(t *testing.T) {
+	podUser, containerUser := int64(1000), int64(2000)
+	tests := []struct {
+		name      string
+		pod       *int64
+		container *int64
+		want      *int64
+		setAt     string
+	}{
+		{"unset", nil, nil, nil, ""},
+		{"pod only", &podUser, nil, &podUser, SetAtPod},
+		{"container only", nil, &containerUser, &containerUser, SetAtContainer},
+		{"container overrides pod", &podUser, &containerUser, &containerUser, SetAtContainer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := PodTarget{Spec: corev1.PodSpec{SecurityContext: &corev1.PodSecurityContext{RunAsUser: tt.pod}}}
+			c := corev1.Container{Name: "app", SecurityContext: &corev1.SecurityContext{RunAsUser: tt.container}}
+			ec := resolveContainer(pod, c, ContainerRegular)
+			if ec.RunAsUser.SetAt != tt.setAt {
+				t.Errorf("SetAt = %q, want %q", ec.RunAsUser.SetAt, tt.setAt)
+			}
+			if (ec.RunAsUser.Value == nil) != (tt.want == nil) || (tt.want != nil && *ec.RunAsUser.Value != *tt.want) {
+				t.Errorf("Value = %v, want %v", ec.RunAsUser.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveContainerNilSecurityContexts(t *testing.T) {
+	ec := resolveContainer(PodTarget{}, corev1.Container{Name: "app"}, ContainerRegular)
+	if ec.RunAsNonRoot.IsSet() || ec.SeccompProfile.IsSet() || ec.HostProcess.IsSet() || ec.RunAsUserName.IsSet() || ec.Privileged.IsSet() {
+		t.Errorf("settings resolved from nil security contexts: %+v", ec)
+	}
+}
+
+func TestResolveContainerSeccomp(t *testing.T) {
+	runtimeDefault := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	unconfined := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}
+	tests := []struct {
+		name        string
+		pod         *corev1.SeccompProfile
+		container   *corev1.SeccompProfile
+		annotations map[string]string
+		want        corev1.SeccompProfileType
+		setAt       string
+	}{
+		{"pod field", runtimeDefault, nil, nil, corev1.SeccompProfileTypeRuntimeDefault, SetAtPod},
+		{"container field overrides pod field", runtimeDefault, unconfined, nil, corev1.SeccompProfileTypeUnconfined, SetAtContainer},
+		{"pod annotation", nil, nil, map[string]string{seccompPodAnnotation: "runtime/default"}, corev1.SeccompProfileTypeRuntimeDefault, SetAtAnnotation},
+		{"pod field overrides pod annotation", unconfined, nil, map[string]string{seccompPodAnnotation: "runtime/default"}, corev1.SeccompProfileTypeUnconfined, SetAtPod},
+		{"container annotation overrides pod field", runtimeDefault, nil, map[string]string{seccompContainerAnnotationPrefix + "app": "unconfined"}, corev1.SeccompProfileTypeUnconfined, SetAtAnnotation},
+		{"container field overrides container annotation", nil, runtimeDefault, map[string]string{seccompContainerAnnotationPrefix + "app": "unconfined"}, corev1.SeccompProfileTypeRuntimeDefault, SetAtContainer},
+		{"annotation for another container is ignored", nil, nil, map[string]string{seccompContainerAnnotationPrefix + "other": "unconfined"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := PodTarget{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+				Spec:       corev1.PodSpec{SecurityContext: &corev1.PodSecurityContext{SeccompProfile: tt.pod}},
+			}
+			c := corev1.Container{Name: "app", SecurityContext: &corev1.SecurityContext{SeccompProfile: tt.container}}
+			ec := resolveContainer(pod, c, ContainerRegular)
+			if ec.SeccompProfile.SetAt != tt.setAt {
+				t.Errorf("SetAt = %q, want %q", ec.SeccompProfile.SetAt, tt.setAt)
+			}
+			var got corev1.SeccompProfileType
+			if ec.SeccompProfile.IsSet() {
+				got = ec.SeccompProfile.Value.Type
+			}
+			if got != tt.want {
+				t.Errorf("Type = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveContainerAppArmor(t *testing.T) {
+	runtimeDefault := &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeRuntimeDefault}
+	unconfined := &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeUnconfined}
+	annotation := map[string]string{apparmorAnnotationPrefix + "app": "unconfined"}
+	tests := []struct {
+		name        string
+		pod         *corev1.AppArmorProfile
+		container   *corev1.AppArmorProfile
+		annotations map[string]string
+		want        corev1.AppArmorProfileType
+		setAt       string
+	}{
+		{"pod field", runtimeDefault, nil, nil, corev1.AppArmorProfileTypeRuntimeDefault, SetAtPod},
+		{"annotation overrides pod field", runtimeDefault, nil, annotation, corev1.AppArmorProfileTypeUnconfined, SetAtAnnotation},
+		{"container field overrides annotation", nil, runtimeDefault, annotation, corev1.AppArmorProfileTypeRuntimeDefault, SetAtContainer},
+		{"container field overrides pod field", runtimeDefault, unconfined, nil, corev1.AppArmorProfileTypeUnconfined, SetAtContainer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := PodTarget{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+				Spec:       corev1.PodSpec{SecurityContext: &corev1.PodSecurityContext{AppArmorProfile: tt.pod}},
+			}
+			c := corev1.Container{Name: "app", SecurityContext: &corev1.SecurityContext{AppArmorProfile: tt.container}}
+			ec := resolveContainer(pod, c, ContainerRegular)
+			if ec.AppArmorProfile.SetAt != tt.setAt {
+				t.Errorf("SetAt = %q, want %q", ec.AppArmorProfile.SetAt, tt.setAt)
+			}
+			if !ec.AppArmorProfile.IsSet() || ec.AppArmorProfile.Value.Type != tt.want {
+				t.Errorf("profile = %+v, want type %q", ec.AppArmorProfile.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffectiveContainerTypes(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	pod := PodTarget{Spec: corev1.PodSpec{
+		InitContainers:      []corev1.Container{{Name: "init"}, {Name: "sidecar", RestartPolicy: &always}},
+		Containers:          []corev1.Container{{Name: "app"}},
+		EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug"}}},
+	}}
+	want := map[string]string{"init": ContainerInit, "sidecar": ContainerSidecar, "app": ContainerRegular, "debug": ContainerEphemeral}
+	containers := EffectiveContainers(pod)
+	if len(containers) != len(want) {
+		t.Fatalf("got %d containers, want %d", len(containers), len(want))
+	}
+	for _, c := range containers {
+		if c.Type != want[c.Name] {
+			t.Errorf("container %s type = %s, want %s", c.Name, c.Type, want[c.Name])
+		}
+	}
+}
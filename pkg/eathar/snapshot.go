@@ -0,0 +1,463 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/pflag"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Snapshot holds the cluster state that checks read from. Each resource type is
+// listed from the API server the first time a check asks for it and then reused,
+// so a run only lists each resource once however many checks need it.
+// A nil list means that resource type hasn't been loaded yet.
+// If a resource type can't be listed, every check that needs it gets the error.
+type Snapshot struct {
+	options   *pflag.FlagSet
+	clientset *kubernetes.Clientset
+	// kubeContext is the kubeconfig context to connect with, if it's not the one picked by the flags
+	kubeContext string
+	// clientErr is set if connecting to the cluster failed, so it's only tried once
+	clientErr error
+	// offline is set when the snapshot wasn't loaded from a live cluster
+	offline bool
+	// errs records resource types that couldn't be listed, so they're not retried by every check
+	errs map[string]error
+
+	pods                *corev1.PodList
+	namespaces          *corev1.NamespaceList
+	nodes               *corev1.NodeList
+	deployments         *appsv1.DeploymentList
+	replicaSets         *appsv1.ReplicaSetList
+	statefulSets        *appsv1.StatefulSetList
+	daemonSets          *appsv1.DaemonSetList
+	jobs                *batchv1.JobList
+	cronJobs            *batchv1.CronJobList
+	clusterRoles        *rbacv1.ClusterRoleList
+	clusterRoleBindings *rbacv1.ClusterRoleBindingList
+	roles               *rbacv1.RoleList
+	roleBindings        *rbacv1.RoleBindingList
+	serverVersion       *version.Info
+	// metadata is only set for snapshots loaded from an archive
+	metadata *ArchiveMetadata
+
+	// targets is built from the lists above the first time it's needed
+	targets []PodTarget
+	// pssRuleCache holds the Pod Security Standards rules picked for each namespace
+	pssRuleCache map[string]pssRules
+	// owners maps ReplicaSets and Jobs to their ownerReferences, for resolving pods to their workloads
+	owners map[string][]metav1.OwnerReference
+	// scopeCache is built from the namespace and selector flags the first time it's needed
+	scopeCache *scope
+}
+
+// PodTarget is something the pod level checks run against. It's either a pod or
+// the pod template of a controller like a Deployment or CronJob.
+type PodTarget struct {
+	metav1.ObjectMeta
+	Kind string
+	Spec corev1.PodSpec
+	// Ref is the manifest file and document the target was loaded from, if any
+	Ref string
+	// Workload is the top level controller that manages the target as Kind/name, e.g. Deployment/web.
+	// BarePod is set for pods that nothing manages.
+	Workload string
+	BarePod  bool
+}
+
+// NewSnapshot creates a snapshot for the cluster selected by the options.
+// Nothing is fetched until a check asks for it.
+func NewSnapshot(options *pflag.FlagSet) *Snapshot {
+	return &Snapshot{options: options, errs: make(map[string]error)}
+}
+
+// NewContextSnapshot creates a snapshot for a named kubeconfig context rather than the one picked by the flags
+func NewContextSnapshot(options *pflag.FlagSet, kubeContext string) *Snapshot {
+	s := NewSnapshot(options)
+	s.kubeContext = kubeContext
+	return s
+}
+
+// LoadSnapshot creates the snapshot a run of checks should use. This is the live cluster
+// unless the options point at manifests or a snapshot archive to scan instead.
+func LoadSnapshot(options *pflag.FlagSet) (*Snapshot, error) {
+	manifests, _ := options.GetStringSlice("from-manifests")
+	archive, _ := options.GetString("from-snapshot")
+	if len(manifests) > 0 && archive != "" {
+		return nil, fmt.Errorf("--from-manifests and --from-snapshot can't be used together")
+	}
+	if len(manifests) > 0 {
+		return LoadManifests(manifests, options)
+	}
+	if archive != "" {
+		return LoadArchive(archive, options)
+	}
+	return NewSnapshot(options), nil
+}
+
+// newOfflineSnapshot creates a snapshot with every resource type already loaded (and empty),
+// ready to be filled from somewhere other than the API server
+func newOfflineSnapshot(options *pflag.FlagSet) *Snapshot {
+	return &Snapshot{
+		options:             options,
+		offline:             true,
+		errs:                make(map[string]error),
+		pods:                &corev1.PodList{},
+		namespaces:          &corev1.NamespaceList{},
+		nodes:               &corev1.NodeList{},
+		deployments:         &appsv1.DeploymentList{},
+		replicaSets:         &appsv1.ReplicaSetList{},
+		statefulSets:        &appsv1.StatefulSetList{},
+		daemonSets:          &appsv1.DaemonSetList{},
+		jobs:                &batchv1.JobList{},
+		cronJobs:            &batchv1.CronJobList{},
+		clusterRoles:        &rbacv1.ClusterRoleList{},
+		clusterRoleBindings: &rbacv1.ClusterRoleBindingList{},
+		roles:               &rbacv1.RoleList{},
+		roleBindings:        &rbacv1.RoleBindingList{},
+	}
+}
+
+// client returns the clientset for the snapshot, connecting on first use
+func (s *Snapshot) client() (*kubernetes.Clientset, error) {
+	if s.offline {
+		return nil, fmt.Errorf("snapshot was not loaded from a cluster")
+	}
+	if s.clientset == nil && s.clientErr == nil {
+		s.clientset, s.clientErr = initKubeClient(s.options, s.kubeContext)
+	}
+	return s.clientset, s.clientErr
+}
+
+// load returns a resource list, calling fetch to list it from the cluster the first time it's needed.
+// Errors are remembered as well, so a list that fails isn't retried by every check.
+func load[T any](s *Snapshot, resource string, list **T, fetch func(c *kubernetes.Clientset) (*T, error)) (*T, error) {
+	if *list != nil {
+		return *list, nil
+	}
+	if err, failed := s.errs[resource]; failed {
+		return nil, err
+	}
+	c, err := s.client()
+	if err == nil {
+		var l *T
+		l, err = fetch(c)
+		if err == nil {
+			*list = l
+			return l, nil
+		}
+	}
+	err = fmt.Errorf("listing %s: %w", resource, err)
+	s.errs[resource] = err
+	return nil, err
+}
+
+// Targets returns everything the pod level checks should look at. That's the running pods plus, unless
+// --templates=false is set, the pod templates of controllers, so workloads with no pods running are still checked.
+func (s *Snapshot) Targets() ([]PodTarget, error) {
+	if s.targets == nil {
+		targets, err := s.buildTargets()
+		if err != nil {
+			return nil, err
+		}
+		s.targets = targets
+	}
+	return s.targets, nil
+}
+
+func (s *Snapshot) buildTargets() ([]PodTarget, error) {
+	targets := []PodTarget{}
+	pods, err := s.Pods()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		workload, bare := s.workloadOf(pod.ObjectMeta)
+		targets = append(targets, PodTarget{ObjectMeta: pod.ObjectMeta, Kind: "Pod", Spec: pod.Spec, Ref: pod.Annotations[RefAnnotation], Workload: workload, BarePod: bare})
+	}
+	if templates, _ := s.options.GetBool("templates"); !templates {
+		return targets, nil
+	}
+	templates, err := s.templateTargets()
+	if err != nil {
+		return nil, err
+	}
+	sc, err := s.scope()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if sc.includesTemplate(t.ObjectMeta, t.Spec) {
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// templateTargets returns the pod templates of every controller. ReplicaSets owned by a Deployment
+// and Jobs owned by a CronJob are skipped, as their owner's template is already checked.
+func (s *Snapshot) templateTargets() ([]PodTarget, error) {
+	var targets []PodTarget
+	deployments, err := s.Deployments()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments {
+		targets = append(targets, templateTarget("Deployment", d.ObjectMeta, d.Spec.Template))
+	}
+	replicaSets, err := s.ReplicaSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets {
+		if controllerOf(rs.OwnerReferences) == nil {
+			targets = append(targets, templateTarget("ReplicaSet", rs.ObjectMeta, rs.Spec.Template))
+		}
+	}
+	statefulSets, err := s.StatefulSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, sts := range statefulSets {
+		targets = append(targets, templateTarget("StatefulSet", sts.ObjectMeta, sts.Spec.Template))
+	}
+	daemonSets, err := s.DaemonSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets {
+		targets = append(targets, templateTarget("DaemonSet", ds.ObjectMeta, ds.Spec.Template))
+	}
+	jobs, err := s.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if controllerOf(job.OwnerReferences) == nil {
+			targets = append(targets, templateTarget("Job", job.ObjectMeta, job.Spec.Template))
+		}
+	}
+	cronJobs, err := s.CronJobs()
+	if err != nil {
+		return nil, err
+	}
+	for _, cj := range cronJobs {
+		targets = append(targets, templateTarget("CronJob", cj.ObjectMeta, cj.Spec.JobTemplate.Spec.Template))
+	}
+	return targets, nil
+}
+
+// templateTarget makes a target from a controller's pod template. The template's own metadata
+// is kept (annotations matter for things like apparmor) but it's named after the controller.
+func templateTarget(kind string, owner metav1.ObjectMeta, template corev1.PodTemplateSpec) PodTarget {
+	meta := template.ObjectMeta
+	meta.Name = owner.Name
+	meta.Namespace = owner.Namespace
+	return PodTarget{ObjectMeta: meta, Kind: kind, Spec: template.Spec, Ref: owner.Annotations[RefAnnotation], Workload: kind + "/" + owner.Name}
+}
+
+// Pods returns the pods in the cluster that are in scope for the checks. The pod and field selectors
+// are passed to the API server, the namespace flags are applied once the pods have been listed.
+func (s *Snapshot) Pods() ([]corev1.Pod, error) {
+	list, err := load(s, "pods", &s.pods, func(c *kubernetes.Clientset) (*corev1.PodList, error) {
+		podSelector, _ := s.options.GetString("pod-selector")
+		fieldSelector, _ := s.options.GetString("field-selector")
+		return c.CoreV1().Pods(s.namespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: podSelector, FieldSelector: fieldSelector})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sc, err := s.scope()
+	if err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range list.Items {
+		if sc.includesPod(pod.ObjectMeta, pod.Spec, pod.Status) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// namespace returns the namespace set with --namespace, or "" for all namespaces.
+// Live clusters are only listed in that namespace, so it works for users who can't list cluster wide.
+func (s *Snapshot) namespace() string {
+	namespace, _ := s.options.GetString("namespace")
+	return namespace
+}
+
+// ServerVersion returns the version of the cluster's API server. This is nil for manifests
+// and for archives collected without it.
+func (s *Snapshot) ServerVersion() (*version.Info, error) {
+	if s.serverVersion != nil || s.offline {
+		return s.serverVersion, nil
+	}
+	if err, failed := s.errs["version"]; failed {
+		return nil, err
+	}
+	c, err := s.client()
+	if err == nil {
+		s.serverVersion, err = c.Discovery().ServerVersion()
+	}
+	if err != nil {
+		err = fmt.Errorf("getting server version: %w", err)
+		s.errs["version"] = err
+		return nil, err
+	}
+	return s.serverVersion, nil
+}
+
+func (s *Snapshot) Namespaces() ([]corev1.Namespace, error) {
+	list, err := load(s, "namespaces", &s.namespaces, func(c *kubernetes.Clientset) (*corev1.NamespaceList, error) {
+		return c.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) Nodes() ([]corev1.Node, error) {
+	list, err := load(s, "nodes", &s.nodes, func(c *kubernetes.Clientset) (*corev1.NodeList, error) {
+		return c.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) Deployments() ([]appsv1.Deployment, error) {
+	list, err := load(s, "deployments", &s.deployments, func(c *kubernetes.Clientset) (*appsv1.DeploymentList, error) {
+		return c.AppsV1().Deployments(s.namespace()).List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) ReplicaSets() ([]appsv1.ReplicaSet, error) {
+	list, err := load(s, "replicasets", &s.replicaSets, func(c *kubernetes.Clientset) (*appsv1.ReplicaSetList, error) {
+		return c.AppsV1().ReplicaSets(s.namespace()).List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) StatefulSets() ([]appsv1.StatefulSet, error) {
+	list, err := load(s, "statefulsets", &s.statefulSets, func(c *kubernetes.Clientset) (*appsv1.StatefulSetList, error) {
+		return c.AppsV1().StatefulSets(s.namespace()).List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) DaemonSets() ([]appsv1.DaemonSet, error) {
+	list, err := load(s, "daemonsets", &s.daemonSets, func(c *kubernetes.Clientset) (*appsv1.DaemonSetList, error) {
+		return c.AppsV1().DaemonSets(s.namespace()).List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) Jobs() ([]batchv1.Job, error) {
+	list, err := load(s, "jobs", &s.jobs, func(c *kubernetes.Clientset) (*batchv1.JobList, error) {
+		return c.BatchV1().Jobs(s.namespace()).List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) CronJobs() ([]batchv1.CronJob, error) {
+	list, err := load(s, "cronjobs", &s.cronJobs, func(c *kubernetes.Clientset) (*batchv1.CronJobList, error) {
+		return c.BatchV1().CronJobs(s.namespace()).List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) ClusterRoles() ([]rbacv1.ClusterRole, error) {
+	list, err := load(s, "clusterroles", &s.clusterRoles, func(c *kubernetes.Clientset) (*rbacv1.ClusterRoleList, error) {
+		return c.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (s *Snapshot) ClusterRoleBindings() ([]rbacv1.ClusterRoleBinding, error) {
+	list, err := load(s, "clusterrolebindings", &s.clusterRoleBindings, func(c *kubernetes.Clientset) (*rbacv1.ClusterRoleBindingList, error) {
+		return c.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Roles returns the Roles in namespaces that are in scope for the checks
+func (s *Snapshot) Roles() ([]rbacv1.Role, error) {
+	list, err := load(s, "roles", &s.roles, func(c *kubernetes.Clientset) (*rbacv1.RoleList, error) {
+		return c.RbacV1().Roles(s.namespace()).List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sc, err := s.scope()
+	if err != nil {
+		return nil, err
+	}
+	var items []rbacv1.Role
+	for _, item := range list.Items {
+		if sc.includesNamespace(item.Namespace) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// RoleBindings returns the RoleBindings in namespaces that are in scope for the checks
+func (s *Snapshot) RoleBindings() ([]rbacv1.RoleBinding, error) {
+	list, err := load(s, "rolebindings", &s.roleBindings, func(c *kubernetes.Clientset) (*rbacv1.RoleBindingList, error) {
+		return c.RbacV1().RoleBindings(s.namespace()).List(context.TODO(), metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sc, err := s.scope()
+	if err != nil {
+		return nil, err
+	}
+	var items []rbacv1.RoleBinding
+	for _, item := range list.Items {
+		if sc.includesNamespace(item.Namespace) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,33 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// riskyVolumeReasons are the volume types that are risky whatever the pod's level, and why
+var riskyVolumeReasons = map[string]string{
+	"gitRepo":    "deprecated, clones the repository on the node as root and has a history of remote code execution bugs (CVE-2018-11235, CVE-2024-10220)",
+	"nfs":        "mounts an NFS share straight from the node, without the access controls of a PersistentVolume",
+	"iscsi":      "attaches an iSCSI block device to the node, with any CHAP credentials in the pod's secret",
+	"rbd":        "attaches a Ceph block device to the node, using a keyring chosen by the pod",
+	"cephfs":     "mounts a CephFS share straight from the node, without the access controls of a PersistentVolume",
+	"flexVolume": "runs a driver binary on the node as root with options chosen by the pod",
+}
+
+// DefaultCSIDrivers are the CSI drivers trusted to provide inline volumes. --allowed-csi-drivers replaces this list.
+var DefaultCSIDrivers = []string{"secrets-store.csi.k8s.io", "csi.cert-manager.io"}
+
+// allowedCSIDrivers returns the CSI driver list from --allowed-csi-drivers, or the default list if the flag isn't there
+func allowedCSIDrivers(options *pflag.FlagSet) []string {
+	if options != nil {
+		if drivers, err := options.GetStringSlice("allowed-csi-drivers"); err == nil {
+			return drivers
+		}
+	}
+	return DefaultCSIDrivers
+}
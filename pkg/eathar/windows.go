@@ -0,0 +1,176 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// windowsAdminUsers are the Windows accounts that have administrator rights in the container. Names are
+// compared without their domain and case, so NT AUTHORITY\SYSTEM matches system.
+var windowsAdminUsers = []string{"containeradministrator", "administrator", "system", "localsystem"}
+
+// windowsNodes returns the names of the nodes running Windows. Not being able to list nodes isn't fatal,
+// it only means pods are judged on their spec alone.
+func (s *Snapshot) windowsNodes() map[string]bool {
+	windows := make(map[string]bool)
+	nodes, err := s.Nodes()
+	if err != nil {
+		return windows
+	}
+	for _, node := range nodes {
+		if node.Labels[corev1.LabelOSStable] == string(corev1.Windows) || node.Status.NodeInfo.OperatingSystem == string(corev1.Windows) {
+			windows[node.Name] = true
+		}
+	}
+	return windows
+}
+
+// windowsReason says why a pod is treated as a Windows pod, or is empty if it isn't one. Only spec.os and
+// running on a Windows node count. windowsOptions are ignored on Linux, so setting them doesn't make a pod a
+// Windows pod.
+func windowsReason(pod PodTarget, windowsNodes map[string]bool) string {
+	switch {
+	case isWindows(pod.Spec):
+		return "spec.os is windows"
+	case windowsNodes[pod.Spec.NodeName]:
+		return "it runs on windows node " + pod.Spec.NodeName
+	}
+	return ""
+}
+
+// selectsWindows reports whether a pod can only be scheduled on Windows nodes, through its nodeSelector
+// or a required node affinity on kubernetes.io/os
+func selectsWindows(spec corev1.PodSpec) bool {
+	if spec.NodeSelector[corev1.LabelOSStable] == string(corev1.Windows) {
+		return true
+	}
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil || spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return false
+	}
+	terms := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		return false
+	}
+	// Terms are ORed, so every one of them has to limit the pod to Windows
+	for _, term := range terms {
+		windows := false
+		for _, req := range term.MatchExpressions {
+			if req.Key == corev1.LabelOSStable && req.Operator == corev1.NodeSelectorOpIn && len(req.Values) == 1 && req.Values[0] == string(corev1.Windows) {
+				windows = true
+			}
+		}
+		if !windows {
+			return false
+		}
+	}
+	return true
+}
+
+func isWindowsAdmin(user string) bool {
+	user = strings.ToLower(user)
+	if i := strings.LastIndex(user, `\`); i >= 0 {
+		user = user[i+1:]
+	}
+	return contains(windowsAdminUsers, user)
+}
+
+// WindowsAdminUser lists containers whose runAsUserName is ContainerAdministrator or another admin account
+func WindowsAdminUser(s *Snapshot) ([]Finding, error) {
+	var admins []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			if container.RunAsUserName.IsSet() && isWindowsAdmin(*container.RunAsUserName.Value) {
+				p := newContainerFinding("Windows Admin User", pod, container)
+				p.Option = "runAsUserName"
+				p.Value = *container.RunAsUserName.Value
+				p.SetAt = container.RunAsUserName.SetAt
+				admins = append(admins, p)
+			}
+		}
+	}
+	return admins, nil
+}
+
+// GMSA lists containers that use a gMSA credential spec, so they can authenticate to Active Directory as that account
+func GMSA(s *Snapshot) ([]Finding, error) {
+	var gmsa []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range targets {
+		for _, container := range EffectiveContainers(pod) {
+			switch {
+			case container.GMSACredentialSpecName.IsSet():
+				p := newContainerFinding("GMSA Credential Spec", pod, container)
+				p.Option = "gmsaCredentialSpecName"
+				p.Value = *container.GMSACredentialSpecName.Value
+				p.SetAt = container.GMSACredentialSpecName.SetAt
+				gmsa = append(gmsa, p)
+			case container.GMSACredentialSpec.IsSet():
+				p := newContainerFinding("GMSA Credential Spec", pod, container)
+				p.Option = "gmsaCredentialSpec"
+				p.Value = "inline"
+				p.SetAt = container.GMSACredentialSpec.SetAt
+				gmsa = append(gmsa, p)
+			}
+		}
+	}
+	return gmsa, nil
+}
+
+// WindowsNodeSelector lists Windows pods that don't select Windows nodes, so they can be scheduled
+// onto Linux nodes and any policies that rely on the kubernetes.io/os label don't see them as Windows pods
+func WindowsNodeSelector(s *Snapshot) ([]Finding, error) {
+	var selectors []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	nodes := s.windowsNodes()
+	for _, pod := range targets {
+		reason := windowsReason(pod, nodes)
+		if reason != "" && !selectsWindows(pod.Spec) {
+			p := newFinding("Windows Node Selector", pod, "")
+			p.Reason = reason
+			selectors = append(selectors, p)
+		}
+	}
+	return selectors, nil
+}
+
+// WindowsHostNetwork lists Windows pods that use the node's network
+func WindowsHostNetwork(s *Snapshot) ([]Finding, error) {
+	var hostnet []Finding
+	targets, err := s.Targets()
+	if err != nil {
+		return nil, err
+	}
+	nodes := s.windowsNodes()
+	for _, pod := range targets {
+		if !pod.Spec.HostNetwork {
+			continue
+		}
+		reason := windowsReason(pod, nodes)
+		// A pod that can only be scheduled on Windows nodes is a Windows pod even before it's scheduled
+		if reason == "" && selectsWindows(pod.Spec) {
+			reason = "it selects windows nodes"
+		}
+		if reason != "" {
+			p := newFinding("Windows Host Network", pod, "")
+			p.Reason = reason
+			hostnet = append(hostnet, p)
+		}
+	}
+	return hostnet, nil
+}
@@ -0,0 +1,102 @@
+package eathar
+
+/*
+Copyright © 2023 Rory McCune <rorym@mccune.org.uk>
+
+*/
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxOwnerDepth stops a broken or circular ownerReferences chain from looping forever.
+// The longest chain we expect is Pod -> Job -> CronJob.
+const maxOwnerDepth = 5
+
+// workloadOf follows a pod's ownerReferences up to the top level controller that manages it,
+// e.g. Pod -> ReplicaSet -> Deployment or Pod -> Job -> CronJob, and returns it as Kind/name.
+// Pods with no owner are returned as Pod/name with bare set.
+func (s *Snapshot) workloadOf(pod metav1.ObjectMeta) (workload string, bare bool) {
+	ref := controllerOf(pod.OwnerReferences)
+	if ref == nil {
+		return "Pod/" + pod.Name, true
+	}
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		parent := controllerOf(s.ownersOf(ref.Kind, pod.Namespace, ref.Name))
+		if parent == nil {
+			break
+		}
+		ref = parent
+	}
+	return ref.Kind + "/" + ref.Name, false
+}
+
+// controllerOf returns the owner that's the controller, or the first owner if none of them are marked as one
+func controllerOf(refs []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return &refs[i]
+		}
+	}
+	if len(refs) > 0 {
+		return &refs[0]
+	}
+	return nil
+}
+
+// ownersOf returns the ownerReferences of an intermediate controller. Only ReplicaSets and Jobs
+// are looked up as they're the only ones that are normally owned by something else.
+// If they can't be listed the chain stops there rather than failing the check.
+func (s *Snapshot) ownersOf(kind, namespace, name string) []metav1.OwnerReference {
+	if s.owners == nil {
+		s.owners = make(map[string][]metav1.OwnerReference)
+		if replicaSets, err := s.ReplicaSets(); err == nil {
+			for _, rs := range replicaSets {
+				s.owners[ownerKey("ReplicaSet", rs.Namespace, rs.Name)] = rs.OwnerReferences
+			}
+		}
+		if jobs, err := s.Jobs(); err == nil {
+			for _, job := range jobs {
+				s.owners[ownerKey("Job", job.Namespace, job.Name)] = job.OwnerReferences
+			}
+		}
+	}
+	return s.owners[ownerKey(kind, namespace, name)]
+}
+
+func ownerKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// groupByWorkload collapses findings for pods managed by the same workload into one finding, with
+// Replicas set to the number of pods. Bare pods and pod templates are left as they are.
+func groupByWorkload(findings []Finding) []Finding {
+	var grouped []Finding
+	index := make(map[string]int)
+	for _, f := range findings {
+		if f.BarePod || f.Kind != "Pod" {
+			grouped = append(grouped, f)
+			continue
+		}
+		key := fmt.Sprintf("%s|%s|%s|%s|%s", f.Check, f.Namespace, f.Workload, f.Container, f.detail())
+		if i, ok := index[key]; ok {
+			grouped[i].Replicas++
+			continue
+		}
+		f.Replicas = 1
+		index[key] = len(grouped)
+		grouped = append(grouped, f)
+	}
+	return grouped
+}
+
+// groupFindings applies --group-by-workload to a check's result
+func groupFindings(r Result, options *pflag.FlagSet) Result {
+	if group, _ := options.GetBool("group-by-workload"); group && r.Findings != nil {
+		r.Findings = groupByWorkload(r.Findings)
+	}
+	return r
+}